@@ -15,34 +15,121 @@
 package auth
 
 import (
-	log "github.com/infostellarinc/stellarcli/pkg/logger"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
 	"github.com/infostellarinc/stellarcli/cmd/util"
 	"github.com/infostellarinc/stellarcli/pkg/auth"
+	"github.com/infostellarinc/stellarcli/pkg/config"
 )
 
 var (
 	activateApiKeyUse   = util.Normalize("activate-api-key [path-to-key]")
 	activateApiKeyShort = util.Normalize("Activate an API key for use in following commands.")
 	activateApiKeyLong  = util.Normalize(`Activates an API key for use in following commands by copying it to the
-		configuration directory.`)
+		configuration directory. The key is checked before it is stored, and its key ID, client email and
+		organisation are shown. The key is stored for the profile selected with --profile, or the profile in use
+		if none is given. The API endpoint of the profile and the TLS settings given with --insecure, --ca-file, --client-cert,
+		--client-key and --tls-server-name are stored with the key.`)
 )
 
 // Create activate-api-key command.
 func NewActivateApiKeyCommand() *cobra.Command {
+	var apiUrl, credentialStore, credentialHelper string
+
 	command := &cobra.Command{
 		Use:   activateApiKeyUse,
 		Short: activateApiKeyShort,
 		Long:  activateApiKeyLong,
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			if err := auth.StoreCredentialsFile(args[0]); err != nil {
-				log.Printf("problem storing configuration: %v\n", err)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, err := config.LoadProfiles()
+			if err != nil {
+				return fmt.Errorf("could not store configuration: %w", err)
+			}
+			name, err := profiles.CurrentName()
+			if err != nil {
+				return util.NewUsageError(err)
+			}
+
+			store, err := auth.NewCredentialStore(credentialStore, credentialHelper)
+			if err != nil {
+				return util.NewUsageError(err)
+			}
+
+			// Only record a profile when it is not the default profile or has settings of its own.
+			profile := profiles.Get(name)
+			if profile == nil || cmd.Flags().Changed("api-url") || hasTLSSettings(cmd) ||
+				credentialStore == auth.StoreHelper {
+				profile = profiles.GetOrCreate(name)
 			}
+
+			info, err := auth.StoreCredentials(args[0], profile, store)
+			if err != nil {
+				return fmt.Errorf("could not activate API key: %w", err)
+			}
+			if cmd.Flags().Changed("api-url") {
+				profile.APIURL = apiUrl
+			}
+			storeTLSSettings(cmd, &profile.TLS)
+			if err := profiles.Save(); err != nil {
+				return fmt.Errorf("could not store configuration: %w", err)
+			}
+
+			fmt.Printf("API key activated for profile %s in the %s credential store.\n", name, store.Name())
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			printKeyInfo(w, info)
+			w.Flush()
+
+			return nil
 		},
 	}
 
+	command.Flags().StringVar(&apiUrl, "api-url", "",
+		"The API endpoint of the profile, e.g. api.stellarstation.com:443. STELLARSTATION_API_URL takes precedence.")
+	command.Flags().StringVar(&credentialStore, "credential-store", auth.StoreFile,
+		"Where to keep the API key. One of: "+strings.Join(auth.CredentialStores, "|")+". 'encrypted' asks for a "+
+			"passphrase, or takes it from "+auth.PassphraseEnv+".")
+	command.Flags().StringVar(&credentialHelper, "credential-helper", "",
+		"The credential helper keeping the API key with --credential-store helper: a name for the command "+
+			"stellar-credential-NAME, a command line, or a shell command line starting with '!'.")
+
 	return command
 }
+
+var tlsFlagNames = []string{"insecure", "ca-file", "client-cert", "client-key", "tls-server-name"}
+
+// Return whether any TLS setting was given on the command line.
+func hasTLSSettings(cmd *cobra.Command) bool {
+	for _, name := range tlsFlagNames {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Store the TLS settings given on the command line in the profile settings.
+func storeTLSSettings(cmd *cobra.Command, settings *config.TLSSettings) {
+	flags := cmd.Flags()
+	if flags.Changed("insecure") {
+		settings.Insecure = nil
+		if insecure, _ := flags.GetBool("insecure"); insecure {
+			settings.Insecure = &insecure
+		}
+	}
+	if flags.Changed("ca-file") {
+		settings.CAFile, _ = flags.GetString("ca-file")
+	}
+	if flags.Changed("client-cert") || flags.Changed("client-key") {
+		settings.CertFile, _ = flags.GetString("client-cert")
+		settings.KeyFile, _ = flags.GetString("client-key")
+	}
+	if flags.Changed("tls-server-name") {
+		settings.ServerName, _ = flags.GetString("tls-server-name")
+	}
+}
@@ -33,6 +33,9 @@ func NewAuthCommand() *cobra.Command {
 	}
 
 	command.AddCommand(NewActivateApiKeyCommand())
+	command.AddCommand(NewListProfilesCommand())
+	command.AddCommand(NewStatusCommand())
+	command.AddCommand(NewUseProfileCommand())
 
 	return command
 }
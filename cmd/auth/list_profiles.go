@@ -0,0 +1,77 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/auth"
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+var (
+	listProfilesUse   = util.Normalize("list-profiles")
+	listProfilesShort = util.Normalize("List the configured profiles.")
+	listProfilesLong  = util.Normalize(`Lists the configured profiles with their API endpoint and whether an API key
+		has been activated for them. The profile in use is marked with *.`)
+)
+
+// Create list-profiles command.
+func NewListProfilesCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   listProfilesUse,
+		Short: listProfilesShort,
+		Long:  listProfilesLong,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, err := config.LoadProfiles()
+			if err != nil {
+				return fmt.Errorf("could not list profiles: %w", err)
+			}
+			current, err := profiles.CurrentName()
+			if err != nil {
+				return util.NewUsageError(err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "CURRENT\tNAME\tAPI URL\tAPI KEY")
+			for _, name := range profiles.Names() {
+				mark := ""
+				if name == current {
+					mark = "*"
+				}
+				apiUrl := profiles.Get(name).APIURL
+				if apiUrl == "" {
+					apiUrl = "(default)"
+				}
+				key := "none"
+				if auth.HasCredentials(name) {
+					key = "activated"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mark, name, apiUrl, key)
+			}
+			w.Flush()
+
+			return nil
+		},
+	}
+
+	return command
+}
@@ -0,0 +1,96 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/auth"
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+var (
+	statusUse   = util.Normalize("status")
+	statusShort = util.Normalize("Show the API key in use.")
+	statusLong  = util.Normalize(`Shows the API key in use, where it comes from and whether it can sign requests.
+		The key in STELLAR_CREDENTIALS takes precedence over the key activated for the profile. Exits with an
+		error if no usable key is found.`)
+)
+
+// Create status command.
+func NewStatusCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   statusUse,
+		Short: statusShort,
+		Long:  statusLong,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, err := config.LoadProfiles()
+			if err != nil {
+				return fmt.Errorf("could not get status: %w", err)
+			}
+
+			name, err := profiles.CurrentName()
+			if err != nil {
+				return util.NewUsageError(err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			defer w.Flush()
+			fmt.Fprintf(w, "Profile:\t%s\n", name)
+
+			location, err := auth.FindDefaultCredentials()
+			if err != nil {
+				return err
+			}
+			source := fmt.Sprintf("%s credential store of profile %s", location.Source, location.Profile)
+			if location.Source == auth.SourceEnv {
+				source = fmt.Sprintf("from %s", auth.CredentialsEnv)
+			}
+			fmt.Fprintf(w, "API key:\t%s (%s)\n", location.Location, source)
+
+			key, err := location.Read()
+			var info *auth.KeyInfo
+			if err == nil {
+				info, err = auth.ParseKey(location.Location, key)
+			}
+			if err != nil {
+				fmt.Fprintln(w, "Status:\tnot usable")
+				return err
+			}
+			printKeyInfo(w, info)
+			fmt.Fprintln(w, "Status:\tusable")
+
+			return nil
+		},
+	}
+
+	return command
+}
+
+// Print the details of an API key as tab separated lines.
+func printKeyInfo(w io.Writer, info *auth.KeyInfo) {
+	fmt.Fprintf(w, "Key ID:\t%s\n", info.KeyID)
+	fmt.Fprintf(w, "Client email:\t%s\n", info.ClientEmail)
+	if info.Organization != "" {
+		fmt.Fprintf(w, "Organization:\t%s\n", info.Organization)
+	}
+}
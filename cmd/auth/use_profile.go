@@ -0,0 +1,70 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/auth"
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+var (
+	useProfileUse   = util.Normalize("use-profile [profile]")
+	useProfileShort = util.Normalize("Set the profile used by following commands.")
+	useProfileLong  = util.Normalize(`Sets the profile used by following commands. The --profile flag and the
+		STELLAR_PROFILE environment variable take precedence over it.`)
+)
+
+// Create use-profile command.
+func NewUseProfileCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   useProfileUse,
+		Short: useProfileShort,
+		Long:  useProfileLong,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if err := config.ValidateProfileName(name); err != nil {
+				return util.NewUsageError(err)
+			}
+			profiles, err := config.LoadProfiles()
+			if err != nil {
+				return fmt.Errorf("could not switch profile: %w", err)
+			}
+			if profiles.Get(name) == nil && !auth.HasCredentials(name) {
+				return util.NewUsageError(fmt.Errorf("profile %s does not exist, activate an API key for it with "+
+					"'stellar auth activate-api-key --profile %s'", name, name))
+			}
+
+			profiles.Active = name
+			if name == config.DefaultProfile {
+				profiles.Active = ""
+			}
+			if err := profiles.Save(); err != nil {
+				return fmt.Errorf("could not switch profile: %w", err)
+			}
+
+			fmt.Printf("Using profile %s.\n", name)
+
+			return nil
+		},
+	}
+
+	return command
+}
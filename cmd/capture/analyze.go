@@ -0,0 +1,113 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/cmd/flag"
+	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
+)
+
+var (
+	analyzeUse   = util.Normalize("analyze [capture file]")
+	analyzeShort = util.Normalize("Prints statistics of a capture file.")
+	analyzeLong  = util.Normalize(
+		`Prints statistics of a capture file. For files written with open-stream --capture-file, the pass
+		summary of every plan is printed as during streaming, followed by frame counts, the frame size
+		distribution, the time span and the CRC32C of the telemetry. For files written with --output-file,
+		which hold no frame boundaries or timestamps, only the size and CRC32C are printed.`)
+)
+
+// Create analyze command.
+func NewAnalyzeCommand() *cobra.Command {
+	passSummaryFlags := flag.NewPassSummaryFlags()
+	flags := flag.NewFlagSet(passSummaryFlags)
+
+	command := &cobra.Command{
+		Use:   analyzeUse,
+		Short: analyzeShort,
+		Long:  analyzeLong,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("accepts 1 arg(s), received %d", len(args))
+			}
+
+			return flags.ValidateAll()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			passSummary, err := passSummaryFlags.ToPassSummaryOptions()
+			if err != nil {
+				return fmt.Errorf("could not create report directory: %w", err)
+			}
+
+			analysis, err := stream.AnalyzeCapture(args[0], passSummary, true)
+			if err != nil {
+				return fmt.Errorf("could not analyze capture file: %w", err)
+			}
+
+			printAnalysis(analysis)
+
+			return nil
+		},
+	}
+
+	// Add flags to the command.
+	flags.AddAllFlags(command)
+
+	return command
+}
+
+func printAnalysis(a *stream.CaptureAnalysis) {
+	fmt.Printf("Capture file: %s\n\n", a.Path)
+	fmt.Printf("  Format                : %s\n", a.Format)
+	if a.SatelliteID != "" {
+		fmt.Printf("  Satellite ID          : %s\n", a.SatelliteID)
+	}
+	fmt.Printf("  Total bytes           : %d\n", a.TotalBytes)
+	fmt.Printf("  CRC32C                : %d (0x%08x)\n", a.CRC32C, a.CRC32C)
+
+	if a.Format == stream.CaptureFormatRaw {
+		fmt.Printf("\n  Raw captures hold no frame boundaries or timestamps; record with --capture-file for full statistics.\n")
+		return
+	}
+
+	fmt.Printf("  Stream responses      : %d\n", a.Messages)
+	fmt.Printf("  Frames                : %d\n", a.Frames)
+	fmt.Printf("  Plans                 : %d\n", len(a.Passes))
+	if s := a.FrameSizes; s != nil {
+		fmt.Printf("  Frame size (bytes)    : min %d, mean %.1f, p50 %d, p90 %d, p99 %d, max %d\n",
+			s.Min, s.Mean, s.P50, s.P90, s.P99, s.Max)
+	}
+	if a.FirstByteReceived != nil {
+		fmt.Printf("  Datatake              : %s - %s (%s)\n", formatTime(a.FirstByteReceived), formatTime(a.LastByteReceived),
+			a.LastByteReceived.Sub(*a.FirstByteReceived))
+	}
+	if a.FirstReceived != nil {
+		fmt.Printf("  Received              : %s - %s (%s)\n", formatTime(a.FirstReceived), formatTime(a.LastReceived),
+			a.LastReceived.Sub(*a.FirstReceived))
+	}
+	if a.Truncated {
+		fmt.Printf("\n  The file ends with an incomplete record, which was ignored.\n")
+	}
+}
+
+func formatTime(t *time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,38 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/cmd/util"
+)
+
+var (
+	captureUse   = util.Normalize("capture")
+	captureShort = util.Normalize("Commands for working with capture files written by open-stream.")
+)
+
+// Create capture command.
+func NewCaptureCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   captureUse,
+		Short: captureShort,
+	}
+
+	command.AddCommand(NewAnalyzeCommand())
+
+	return command
+}
@@ -0,0 +1,136 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/infostellarinc/stellarcli/cmd/flag"
+	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+var (
+	configUse   = util.Normalize("config")
+	configShort = util.Normalize("Commands for managing flag defaults.")
+	configLong  = util.Normalize(`Commands for managing flag defaults stored in config.yaml in the configuration
+		directory, or in a profile when --profile is given.
+
+		A key is either a flag name, which applies to every command with that flag, or a command path and a flag
+		name separated by dots, which only applies to that command and takes precedence, e.g.
+
+		$ stellar config set output csv
+		$ stellar config set satellite.open-stream.stats true
+
+		Defaults are applied in the order: flag, environment variable (STELLAR_ followed by the flag name in upper
+		case with - replaced by _, e.g. STELLAR_OUTPUT), profile, config.yaml, built-in default.`)
+)
+
+// Create config command.
+func NewConfigCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   configUse,
+		Short: configShort,
+		Long:  configLong,
+		// The configuration must be manageable while it cannot be loaded, e.g. to repair it.
+		Annotations: map[string]string{flag.SkipDefaultsAnnotation: "true"},
+	}
+
+	command.AddCommand(NewGetCommand())
+	command.AddCommand(NewListCommand())
+	command.AddCommand(NewSetCommand())
+	command.AddCommand(NewUnsetCommand())
+
+	return command
+}
+
+// Return the profiles and the profile whose defaults are managed, or nil to manage config.yaml. profiles.yaml is only
+// read with --profile, so that config.yaml can be managed while profiles.yaml is broken.
+func selectedProfile(cmd *cobra.Command) (*config.Profiles, *config.Profile, error) {
+	if !cmd.Flags().Changed("profile") {
+		return nil, nil, nil
+	}
+	profiles, err := config.LoadProfiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	name, err := profiles.CurrentName()
+	if err != nil {
+		return nil, nil, util.NewUsageError(err)
+	}
+	return profiles, profiles.GetOrCreate(name), nil
+}
+
+// Find the flag a key refers to. Keys without a command path must refer to a flag of at least one command.
+func findFlag(root *cobra.Command, key string) (*pflag.Flag, error) {
+	path, name := config.SplitSettingKey(key)
+	if len(path) == 0 {
+		if f := findFlagInTree(root, name); f != nil {
+			return f, nil
+		}
+		return nil, fmt.Errorf("unknown key %q: no command has a --%s flag", key, name)
+	}
+
+	cmd := root
+	for _, name := range path {
+		var next *cobra.Command
+		for _, c := range cmd.Commands() {
+			if c.Name() == name || c.HasAlias(name) {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			return nil, fmt.Errorf("unknown key %q: no command %s", key, strings.Join(path, " "))
+		}
+		cmd = next
+	}
+	if f := lookupFlag(cmd, name); f != nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unknown key %q: %s has no --%s flag", key, strings.Join(path, " "), name)
+}
+
+func findFlagInTree(cmd *cobra.Command, name string) *pflag.Flag {
+	if f := lookupFlag(cmd, name); f != nil {
+		return f
+	}
+	for _, c := range cmd.Commands() {
+		if f := findFlagInTree(c, name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func lookupFlag(cmd *cobra.Command, name string) *pflag.Flag {
+	if !flag.AcceptsDefault(name) {
+		return nil
+	}
+	if f := cmd.Flags().Lookup(name); f != nil {
+		return f
+	}
+	return cmd.InheritedFlags().Lookup(name)
+}
+
+// Make sure the key is valid before it is looked up or changed.
+func validateKey(cmd *cobra.Command, key string) error {
+	_, err := findFlag(cmd.Root(), key)
+	return err
+}
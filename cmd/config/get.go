@@ -0,0 +1,63 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+var (
+	getUse   = util.Normalize("get [key]")
+	getShort = util.Normalize("Print the default of a flag.")
+	getLong  = util.Normalize(`Prints the default that applies for a key, taking the environment, the profile
+		in use and config.yaml into account. Exits with an error if no default is set.`)
+)
+
+// Create get command.
+func NewGetCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   getUse,
+		Short: getShort,
+		Long:  getLong,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+				return err
+			}
+			return validateKey(cmd, args[0])
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defaults, err := config.LoadDefaults()
+			if err != nil {
+				return fmt.Errorf("could not get %s: %w", args[0], err)
+			}
+
+			path, name := config.SplitSettingKey(args[0])
+			value, _, ok := defaults.Lookup(path, name)
+			if !ok {
+				return fmt.Errorf("%s is not set", args[0])
+			}
+			fmt.Println(value)
+
+			return nil
+		},
+	}
+
+	return command
+}
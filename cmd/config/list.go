@@ -0,0 +1,67 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+var (
+	listUse   = util.Normalize("list")
+	listShort = util.Normalize("List the flag defaults.")
+	listLong  = util.Normalize(`Lists the flag defaults of the profile in use and of config.yaml. Defaults of the
+		profile take precedence over config.yaml.`)
+)
+
+// Create list command.
+func NewListCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   listUse,
+		Short: listShort,
+		Long:  listLong,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profile, err := config.CurrentProfile()
+			if err != nil {
+				return fmt.Errorf("could not list defaults: %w", err)
+			}
+			settings, err := config.LoadSettings()
+			if err != nil {
+				return fmt.Errorf("could not list defaults: %w", err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+			for _, key := range profile.Defaults.Keys() {
+				fmt.Fprintf(w, "%s\t%s\tprofile %s\n", key, profile.Defaults[key], profile.Name)
+			}
+			for _, key := range settings.Keys() {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", key, settings[key], config.GetSettingsFile())
+			}
+			w.Flush()
+
+			return nil
+		},
+	}
+
+	return command
+}
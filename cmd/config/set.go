@@ -0,0 +1,81 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+var (
+	setUse   = util.Normalize("set [key] [value]")
+	setShort = util.Normalize("Set a flag default.")
+	setLong  = util.Normalize(`Sets a flag default in config.yaml, or in the profile given with --profile. The
+		value is given as on the command line, e.g. AX25,BITSTREAM for a list.`)
+)
+
+// Create set command.
+func NewSetCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   setUse,
+		Short: setShort,
+		Long:  setLong,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+				return err
+			}
+			f, err := findFlag(cmd.Root(), args[0])
+			if err != nil {
+				return err
+			}
+			if err := f.Value.Set(args[1]); err != nil {
+				return fmt.Errorf("invalid value %q for --%s: %v", args[1], f.Name, err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, value := args[0], args[1]
+
+			profiles, profile, err := selectedProfile(cmd)
+			if err != nil {
+				return fmt.Errorf("could not set %s: %w", key, err)
+			}
+			if profile != nil {
+				if profile.Defaults == nil {
+					profile.Defaults = config.Settings{}
+				}
+				profile.Defaults[key] = value
+				err = profiles.Save()
+			} else {
+				var settings config.Settings
+				if settings, err = config.LoadSettings(); err == nil {
+					settings[key] = value
+					err = settings.Save()
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("could not set %s: %w", key, err)
+			}
+
+			return nil
+		},
+	}
+
+	return command
+}
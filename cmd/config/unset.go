@@ -0,0 +1,71 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+var (
+	unsetUse   = util.Normalize("unset [key]")
+	unsetShort = util.Normalize("Remove a flag default.")
+	unsetLong  = util.Normalize(`Removes a flag default from config.yaml, or from the profile given with
+		--profile.`)
+)
+
+// Create unset command.
+func NewUnsetCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   unsetUse,
+		Short: unsetShort,
+		Long:  unsetLong,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+				return err
+			}
+			return validateKey(cmd, args[0])
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := args[0]
+
+			profiles, profile, err := selectedProfile(cmd)
+			if err != nil {
+				return fmt.Errorf("could not unset %s: %w", key, err)
+			}
+			if profile != nil {
+				delete(profile.Defaults, key)
+				err = profiles.Save()
+			} else {
+				var settings config.Settings
+				if settings, err = config.LoadSettings(); err == nil {
+					delete(settings, key)
+					err = settings.Save()
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("could not unset %s: %w", key, err)
+			}
+
+			return nil
+		},
+	}
+
+	return command
+}
@@ -0,0 +1,139 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dashboard implements the terminal dashboard of open-stream.
+package dashboard
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
+)
+
+// maxLogLines - Number of log lines kept for the event log
+const maxLogLines = 500
+
+// Dashboard collects what the stream reports for the terminal view. Its methods are safe for concurrent use and
+// never block on the view, so that the stream can report before the view starts and after it exits.
+type Dashboard struct {
+	view *stream.StatsView
+
+	mu          sync.Mutex
+	lines       []string
+	partial     string
+	antenna     *stream.AntennaEvent
+	receiver    *stream.ReceiverEvent
+	transmitter *stream.TransmitterEvent
+	lastEvent   time.Time
+	program     *tea.Program
+}
+
+// New creates a dashboard showing the statistics of view.
+func New(view *stream.StatsView) *Dashboard {
+	return &Dashboard{
+		view: view,
+	}
+}
+
+// EventSink returns a sink showing the ground station state of monitoring events on the dashboard.
+func (d *Dashboard) EventSink() stream.EventSink {
+	return stream.EventSinkFunc(func(e *stream.MonitoringEvent) error {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		if e.Receiver != nil && d.receiver != nil {
+			if e.Receiver.FrameSynchronizerLocked != d.receiver.FrameSynchronizerLocked {
+				d.appendLine(fmt.Sprintf("%s frame lock %s", e.Time.Local().Format("15:04:05"), lockState(e.Receiver.FrameSynchronizerLocked)))
+			}
+			if e.Receiver.PhaseLocked != d.receiver.PhaseLocked {
+				d.appendLine(fmt.Sprintf("%s phase lock %s", e.Time.Local().Format("15:04:05"), lockState(e.Receiver.PhaseLocked)))
+			}
+		}
+		if e.Antenna != nil {
+			d.antenna = e.Antenna
+		}
+		if e.Receiver != nil {
+			d.receiver = e.Receiver
+		}
+		if e.Transmitter != nil {
+			d.transmitter = e.Transmitter
+		}
+		d.lastEvent = e.Time
+		return nil
+	})
+}
+
+func lockState(locked bool) string {
+	if locked {
+		return "acquired"
+	}
+	return "lost"
+}
+
+// Write adds log output to the event log of the dashboard; pass the dashboard to log.SetOutput while it runs.
+func (d *Dashboard) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	text := d.partial + strings.ReplaceAll(string(p), "\r", "\n")
+	lines := strings.Split(text, "\n")
+	d.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		if line != "" {
+			d.appendLine(line)
+		}
+	}
+	return len(p), nil
+}
+
+// must be called with mu held
+func (d *Dashboard) appendLine(line string) {
+	d.lines = append(d.lines, line)
+	if len(d.lines) > maxLogLines {
+		d.lines = d.lines[len(d.lines)-maxLogLines:]
+	}
+}
+
+// state returns what the stream reported since the dashboard was created
+func (d *Dashboard) state() (lines []string, antenna *stream.AntennaEvent, receiver *stream.ReceiverEvent, transmitter *stream.TransmitterEvent, lastEvent time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.lines...), d.antenna, d.receiver, d.transmitter, d.lastEvent
+}
+
+// Run shows the dashboard until the user quits or Quit is called.
+func (d *Dashboard) Run() error {
+	p := tea.NewProgram(newModel(d), tea.WithAltScreen())
+	d.mu.Lock()
+	d.program = p
+	d.mu.Unlock()
+
+	_, err := p.Run()
+	return err
+}
+
+// Quit stops the dashboard.
+func (d *Dashboard) Quit() {
+	d.mu.Lock()
+	p := d.program
+	d.mu.Unlock()
+	if p != nil {
+		p.Quit()
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dashboard
+
+import (
+	"log"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
+)
+
+var _ = Describe("Dashboard", func() {
+
+	It("collects log output line by line", func() {
+		d := New(stream.NewStatsView())
+		_, _ = d.Write([]byte("first\nsec"))
+		_, _ = d.Write([]byte("ond\r\n"))
+
+		lines, _, _, _, _ := d.state()
+		Expect(lines).To(Equal([]string{"first", "second"}))
+	})
+
+	It("captures the standard logger while it is its output", func() {
+		d := New(stream.NewStatsView())
+		log.SetOutput(d)
+		log.Println("reconnecting to the API stream.")
+		log.SetOutput(GinkgoWriter)
+
+		lines, _, _, _, _ := d.state()
+		Expect(lines).To(HaveLen(1))
+		Expect(lines[0]).To(HaveSuffix("reconnecting to the API stream."))
+	})
+
+	It("keeps the last event log lines", func() {
+		d := New(stream.NewStatsView())
+		for i := 0; i < maxLogLines+10; i++ {
+			_, _ = d.Write([]byte("line\n"))
+		}
+
+		lines, _, _, _, _ := d.state()
+		Expect(lines).To(HaveLen(maxLogLines))
+	})
+
+	It("logs receiver lock changes of monitoring events", func() {
+		d := New(stream.NewStatsView())
+		sink := d.EventSink()
+		at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+		Expect(sink.Send(&stream.MonitoringEvent{Time: at, Receiver: &stream.ReceiverEvent{}})).To(Succeed())
+		Expect(sink.Send(&stream.MonitoringEvent{Time: at, Receiver: &stream.ReceiverEvent{PhaseLocked: true}})).To(Succeed())
+
+		lines, _, receiver, _, lastEvent := d.state()
+		Expect(lines).To(Equal([]string{"12:00:00 phase lock acquired"}))
+		Expect(receiver.PhaseLocked).To(BeTrue())
+		Expect(lastEvent).To(Equal(at))
+	})
+})
+
+var _ = Describe("model", func() {
+
+	It("shows the state of the dashboard on refresh", func() {
+		d := New(stream.NewStatsView())
+		_, _ = d.Write([]byte("connected to the API stream.\n"))
+
+		updated, cmd := newModel(d).Update(refreshTick{})
+		Expect(cmd).NotTo(BeNil())
+		m := updated.(model)
+		Expect(m.rates).To(HaveLen(1))
+		Expect(m.View()).To(ContainSubstring("connected to the API stream."))
+	})
+
+	It("quits on q", func() {
+		_, cmd := newModel(New(stream.NewStatsView())).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+		Expect(cmd).NotTo(BeNil())
+		Expect(cmd()).To(Equal(tea.Quit()))
+	})
+
+	It("fits the terminal width", func() {
+		updated, _ := newModel(New(stream.NewStatsView())).Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+		Expect(updated.(model).width).To(Equal(80))
+
+		updated, _ = newModel(New(stream.NewStatsView())).Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+		Expect(updated.(model).width).To(Equal(tableWidth))
+	})
+
+	It("keeps the last samples for the sparklines", func() {
+		var samples []float64
+		for i := 0; i < sparklineSamples+5; i++ {
+			samples = appendSample(samples, float64(i))
+		}
+		Expect(samples).To(HaveLen(sparklineSamples))
+		Expect(samples[0]).To(Equal(5.0))
+	})
+})
+
+var _ = Describe("formatting", func() {
+
+	It("formats rates and sizes", func() {
+		Expect(formatRate(999)).To(Equal("999 bps"))
+		Expect(formatRate(1_500_000)).To(Equal("1.5 Mbps"))
+		Expect(humanBytes(512)).To(Equal("512 B"))
+		Expect(humanBytes(3 << 20)).To(Equal("3.0 MiB"))
+	})
+})
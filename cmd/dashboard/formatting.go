@@ -0,0 +1,116 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dashboard
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var tableWidth = 120
+
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws the last width samples scaled to the largest of them
+func sparkline(samples []float64, width int) string {
+	if len(samples) > width {
+		samples = samples[len(samples)-width:]
+	}
+	highest := 0.0
+	for _, v := range samples {
+		highest = max(highest, v)
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", width-len(samples)))
+	for _, v := range samples {
+		level := 0
+		if highest > 0 {
+			level = int(v / highest * float64(len(sparklineLevels)-1))
+		}
+		b.WriteRune(sparklineLevels[max(0, level)])
+	}
+	return sparklineStyle.Render(b.String())
+}
+
+func formatRate(bps int64) string {
+	switch {
+	case bps >= 1e9:
+		return fmt.Sprintf("%.1f Gbps", float64(bps)/1e9)
+	case bps >= 1e6:
+		return fmt.Sprintf("%.1f Mbps", float64(bps)/1e6)
+	case bps >= 1e3:
+		return fmt.Sprintf("%.1f kbps", float64(bps)/1e3)
+	}
+	return fmt.Sprintf("%d bps", bps)
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Truncate(10 * time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Truncate(10 * time.Microsecond).String()
+	}
+	return d.String()
+}
+
+func humanBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+var baseRenderer = lipgloss.NewRenderer(os.Stdout)
+var baseText = baseRenderer.NewStyle().TabWidth(2).Foreground(lipgloss.Color("252"))
+var boldStyle = baseText.Copy().Bold(true)
+var helpStyle = baseText.Copy().Foreground(lipgloss.Color("244"))
+var sparklineStyle = baseText.Copy().Foreground(lipgloss.Color("#75C8FB"))
+
+var headerStyle = boldStyle.Copy().Foreground(lipgloss.Color("252"))
+var tableContent = baseText.Copy().Foreground(lipgloss.Color("252"))
+
+var greenStyle = baseText.Copy().Foreground(lipgloss.Color("#75FBAB"))
+var redStyle = baseText.Copy().Foreground(lipgloss.Color("#FF7698"))
+
+func greenRedBoolText(isGreen bool, text string) string {
+	if isGreen {
+		return greenStyle.Render(text)
+	}
+	return redStyle.Render(text)
+}
+
+func tableStyleFunc(row, col int) lipgloss.Style {
+	if row == 0 {
+		return headerStyle
+	}
+	return tableContent
+}
+
+var viewportStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("248")).
+	PaddingRight(2)
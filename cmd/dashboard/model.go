@@ -0,0 +1,213 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dashboard
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
+)
+
+// Number of samples shown in the sparklines, one per refresh.
+const sparklineSamples = 60
+
+const refreshInterval = time.Second
+
+var quitKey = key.NewBinding(
+	key.WithKeys("q", "ctrl+c"),
+	key.WithHelp("q", "quit"),
+)
+
+type refreshTick struct{}
+
+func refresh() tea.Cmd {
+	return tea.Tick(refreshInterval, func(time.Time) tea.Msg {
+		return refreshTick{}
+	})
+}
+
+type model struct {
+	dashboard *Dashboard
+
+	stats       stream.StreamStats
+	rates       []float64
+	delays      []float64
+	antenna     *stream.AntennaEvent
+	receiver    *stream.ReceiverEvent
+	transmitter *stream.TransmitterEvent
+	lastEvent   time.Time
+
+	width  int
+	logs   viewport.Model
+	follow bool
+}
+
+func newModel(d *Dashboard) model {
+	return model{
+		dashboard: d,
+		width:     tableWidth,
+		logs:      viewport.New(tableWidth-viewportStyle.GetHorizontalFrameSize(), 10),
+		follow:    true,
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return refresh()
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case refreshTick:
+		m.sample()
+		return m, refresh()
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, tableWidth)
+		m.logs.Width = m.width - viewportStyle.GetHorizontalFrameSize()
+		// leave room for the panels above the event log
+		m.logs.Height = max(5, msg.Height-28)
+		return m, nil
+	case tea.KeyMsg:
+		if key.Matches(msg, quitKey) {
+			return m, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	m.logs, cmd = m.logs.Update(msg)
+	// keep following new log lines unless the user scrolled up
+	m.follow = m.logs.AtBottom()
+	return m, cmd
+}
+
+// take a sample of the stream statistics and state
+func (m *model) sample() {
+	m.stats = m.dashboard.view.Stats()
+	m.rates = appendSample(m.rates, float64(m.stats.RateBps))
+	m.delays = appendSample(m.delays, float64(m.stats.Delay))
+
+	var lines []string
+	lines, m.antenna, m.receiver, m.transmitter, m.lastEvent = m.dashboard.state()
+	m.logs.SetContent(strings.Join(lines, "\n"))
+	if m.follow {
+		m.logs.GotoBottom()
+	}
+}
+
+func appendSample(samples []float64, v float64) []float64 {
+	samples = append(samples, v)
+	if len(samples) > sparklineSamples {
+		samples = samples[len(samples)-sparklineSamples:]
+	}
+	return samples
+}
+
+func (m model) View() string {
+	s := m.stats
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s %s   %s %s   %s %s\n\n",
+		boldStyle.Render("Satellite"), orNone(s.SatelliteID),
+		boldStyle.Render("Plan"), orNone(s.PlanID),
+		boldStyle.Render("Stream"), orNone(s.StreamID))
+
+	fmt.Fprintf(&b, "%s %s %s\n", boldStyle.Render("Rate "), sparkline(m.rates, sparklineSamples),
+		formatRate(s.RateBps))
+	fmt.Fprintf(&b, "%s %s %s\n\n", boldStyle.Render("Delay"), sparkline(m.delays, sparklineSamples),
+		formatDuration(s.Delay))
+
+	noData := ""
+	if !s.LastReceived.IsZero() {
+		noData = formatDuration(time.Since(s.LastReceived).Truncate(time.Second))
+	}
+	skew := "not measured"
+	if s.ClockOffset != nil {
+		skew = s.ClockOffset.String()
+	}
+	b.WriteString(newTable(m.width,
+		[]string{"Total Bytes", "Chunks", "Avg Rate", "Avg Delay", "Delay p90", "Since Last Data", "Gaps", "Clock Skew"},
+		[]string{humanBytes(s.TotalBytes), fmt.Sprint(s.TotalMessages), formatRate(s.AverageRateBps),
+			formatDuration(s.AverageDelay), formatDuration(s.DelayP90), noData, fmt.Sprint(s.Gaps), skew},
+	).Render())
+	b.WriteString("\n")
+	b.WriteString(newTable(m.width,
+		[]string{"Proxy Clients", "Reconnects", "Commands Sent", "Commands Failed", "Commands Dropped", "Dropped Frames"},
+		[]string{fmt.Sprint(s.ProxyClients), fmt.Sprint(s.Reconnects), fmt.Sprint(s.CommandsSent),
+			fmt.Sprint(s.CommandsFailed), fmt.Sprint(s.CommandsDropped), fmt.Sprint(s.DroppedFrames)},
+	).Render())
+	b.WriteString("\n")
+	b.WriteString(m.groundStationTable().Render())
+	b.WriteString("\n")
+
+	b.WriteString(boldStyle.Render("Event log"))
+	b.WriteString("\n")
+	b.WriteString(viewportStyle.Render(m.logs.View()))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("q: quit  ↑/↓: scroll event log"))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// groundStationTable shows the antenna and receiver state of the last monitoring event
+func (m model) groundStationTable() *table.Table {
+	azimuth, elevation := "-", "-"
+	if a := m.antenna; a != nil {
+		azimuth = fmt.Sprintf("%.1f° (cmd %.1f°)", a.AzimuthMeasured, a.AzimuthCommand)
+		elevation = fmt.Sprintf("%.1f° (cmd %.1f°)", a.ElevationMeasured, a.ElevationCommand)
+	}
+	frequency, lock, snr, carrier := "-", "-", "-", "-"
+	if r := m.receiver; r != nil {
+		frequency = fmt.Sprintf("%.3f MHz", float64(r.CenterFrequencyHz)/1e6)
+		lock = fmt.Sprintf("%s/%s/%s",
+			greenRedBoolText(r.PhaseLocked, "phase"),
+			greenRedBoolText(r.BitSynchronizerLocked, "bit"),
+			greenRedBoolText(r.FrameSynchronizerLocked, "frame"))
+		snr = fmt.Sprintf("%.2f", r.NormalizedSnr)
+		carrier = fmt.Sprintf("%.1f dBm", r.CarrierLevelDbm)
+	}
+	transmitter := "-"
+	if t := m.transmitter; t != nil {
+		transmitter = fmt.Sprintf("%s %s",
+			greenRedBoolText(t.CarrierEnabled, "carrier"),
+			greenRedBoolText(t.ModulationEnabled, "modulation"))
+	}
+	updated := "-"
+	if !m.lastEvent.IsZero() {
+		updated = m.lastEvent.Local().Format("15:04:05")
+	}
+	return newTable(m.width,
+		[]string{"Azimuth", "Elevation", "Rx Frequency", "Rx Lock", "SNR", "Carrier", "Tx", "Updated"},
+		[]string{azimuth, elevation, frequency, lock, snr, carrier, transmitter, updated})
+}
+
+func newTable(width int, headers, row []string) *table.Table {
+	return table.New().Width(width).Headers(headers...).Row(row...).
+		Border(lipgloss.RoundedBorder()).StyleFunc(tableStyleFunc)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
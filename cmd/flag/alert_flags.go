@@ -0,0 +1,127 @@
+//
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
+	"github.com/infostellarinc/stellarcli/pkg/satellite/plan"
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
+)
+
+type AlertFlags struct {
+	NoData         time.Duration
+	DelayP90       time.Duration
+	MinRatePercent float64
+	MaxReconnects  int64
+
+	Exec    string
+	Webhook string
+	File    string
+}
+
+// Add flags to the command.
+func (f *AlertFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVarP(&f.NoData, "alert-no-data", "", 0,
+		"Alert when no telemetry is received for this long between the AOS and LOS of the plan, e.g. 30s. (default disabled)")
+	cmd.Flags().DurationVarP(&f.DelayP90, "alert-delay-p90", "", 0,
+		"Alert when the 90th percentile of the telemetry delay of the plan exceeds this duration, e.g. 5s. (default disabled)")
+	cmd.Flags().Float64VarP(&f.MinRatePercent, "alert-min-rate-percent", "", 0,
+		"Alert when the data rate drops below this percentage of the downlink bitrate of the plan's channel set, e.g. 50. (default disabled)")
+	cmd.Flags().Int64VarP(&f.MaxReconnects, "alert-max-reconnects", "", 0,
+		"Alert when the stream reconnects to the API more than this many times during a plan. (default disabled)")
+	cmd.Flags().StringVarP(&f.Exec, "alert-exec", "", "",
+		"Shell command to run for every alert. The alert is passed as JSON on standard input and in STELLAR_ALERT_* environment variables. (default none)")
+	cmd.Flags().StringVarP(&f.Webhook, "alert-webhook", "", "",
+		"URL to POST every alert to as JSON. (default none)")
+	cmd.Flags().StringVarP(&f.File, "alert-file", "", "",
+		"The file to append alerts to as JSON Lines. (default none)")
+}
+
+func (f *AlertFlags) hasRules() bool {
+	return f.NoData > 0 || f.DelayP90 > 0 || f.MinRatePercent > 0 || f.MaxReconnects > 0
+}
+
+func (f *AlertFlags) hasActions() bool {
+	return f.Exec != "" || f.Webhook != "" || f.File != ""
+}
+
+// Validate flag values.
+func (f *AlertFlags) Validate() error {
+	if f.NoData < 0 || f.DelayP90 < 0 {
+		return errors.New("invalid alert threshold: durations must not be negative")
+	}
+	if f.MinRatePercent < 0 || f.MinRatePercent > 100 {
+		return fmt.Errorf("invalid value of alert-min-rate-percent: %v. Expected a percentage between 0 and 100", f.MinRatePercent)
+	}
+	if f.MaxReconnects < 0 {
+		return fmt.Errorf("invalid value of alert-max-reconnects: %v. Expected a non-negative number", f.MaxReconnects)
+	}
+	if f.Webhook != "" {
+		u, err := url.Parse(f.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid alert webhook: %v. Expected an http or https URL", f.Webhook)
+		}
+	}
+	if f.hasRules() && !f.hasActions() {
+		return errors.New("alert rules require --alert-exec, --alert-webhook or --alert-file")
+	}
+	if f.hasActions() && !f.hasRules() {
+		return errors.New("--alert-exec, --alert-webhook and --alert-file require an alert rule")
+	}
+
+	return nil
+}
+
+// Return the alert options configured by the flags for the given satellite, or nil when no rule is set. Plans are
+// looked up with the given client.
+func (f *AlertFlags) ToAlertOptions(client *apiclient.Client, satelliteId string) *stream.AlertOptions {
+	if !f.hasRules() {
+		return nil
+	}
+
+	o := &stream.AlertOptions{
+		Rules: stream.AlertRules{
+			NoData:         f.NoData,
+			DelayP90:       f.DelayP90,
+			MinRatePercent: f.MinRatePercent,
+			MaxReconnects:  f.MaxReconnects,
+		},
+		Command:    f.Exec,
+		WebhookURL: f.Webhook,
+		File:       f.File,
+	}
+	// Only look up plans when a rule needs their AOS, LOS or bitrate.
+	if f.NoData > 0 || f.MinRatePercent > 0 {
+		o.PlanLookup = func(planId string) (*stellarstation.Plan, error) {
+			return plan.GetPlan(client, satelliteId, planId)
+		}
+	}
+
+	return o
+}
+
+// Create a new AlertFlags with default values set.
+func NewAlertFlags() *AlertFlags {
+	return &AlertFlags{}
+}
@@ -0,0 +1,60 @@
+//
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
+)
+
+type AuditLogFlags struct {
+	AuditLog        string
+	AuditLogPayload bool
+}
+
+// Add flags to the command.
+func (f *AuditLogFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.AuditLog, "audit-log", "", "",
+		"The file to append a record of every request sent to the satellite to as JSON Lines. (default none)")
+	cmd.Flags().BoolVarP(&f.AuditLogPayload, "audit-log-payload", "", false,
+		"Include the payload in audit log records in addition to its SHA-256. Requires --audit-log.")
+}
+
+// Validate flag values.
+func (f *AuditLogFlags) Validate() error {
+	if f.AuditLogPayload && f.AuditLog == "" {
+		return errors.New("--audit-log-payload requires --audit-log")
+	}
+
+	return nil
+}
+
+// Return the audit log configured by the flags, or nil when audit logging is disabled.
+func (f *AuditLogFlags) ToCommandAuditLog() (*stream.CommandAuditLog, error) {
+	if f.AuditLog == "" {
+		return nil, nil
+	}
+
+	return stream.OpenCommandAuditLog(f.AuditLog, f.AuditLogPayload)
+}
+
+// Create a new AuditLogFlags with default values set.
+func NewAuditLogFlags() *AuditLogFlags {
+	return &AuditLogFlags{}
+}
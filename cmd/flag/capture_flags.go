@@ -0,0 +1,52 @@
+//
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
+)
+
+type CaptureFlags struct {
+	CaptureFile string
+}
+
+// Add flags to the command.
+func (f *CaptureFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.CaptureFile, "capture-file", "", "",
+		"The file to record every stream response to, with its plan, ground station, framing and timestamps, for "+
+			"analysis with 'stellar capture analyze'. Creates file if it does not exist; appends to file if it already exists. (default none)")
+}
+
+// Validate flag values.
+func (f *CaptureFlags) Validate() error {
+	return nil
+}
+
+// Return the capture writer for the satellite, or nil when no capture file is set.
+func (f *CaptureFlags) ToCaptureWriter(satelliteId string) (*stream.CaptureWriter, error) {
+	if f.CaptureFile == "" {
+		return nil, nil
+	}
+
+	return stream.CreateCaptureFile(f.CaptureFile, satelliteId)
+}
+
+// Create a new CaptureFlags.
+func NewCaptureFlags() *CaptureFlags {
+	return &CaptureFlags{}
+}
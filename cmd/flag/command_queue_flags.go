@@ -0,0 +1,91 @@
+//
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
+)
+
+var (
+	// Default maximum number of queued commands.
+	defaultCommandQueueDepth = 1000
+)
+
+type CommandQueueFlags struct {
+	CommandRate            float64
+	CommandBitrate         uint64
+	CommandBitrateFromPlan bool
+	CommandQueueDepth      int
+}
+
+// Add flags to the command.
+func (f *CommandQueueFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().Float64VarP(&f.CommandRate, "command-rate", "", 0,
+		"The maximum number of commands sent to the satellite per second. Commands above the rate are queued. (default unlimited)")
+	cmd.Flags().Uint64VarP(&f.CommandBitrate, "command-bitrate", "", 0,
+		"The maximum uplink rate in bits per second. Commands above the rate are queued. (default unlimited)")
+	cmd.Flags().BoolVarP(&f.CommandBitrateFromPlan, "command-bitrate-from-plan", "", false,
+		"Limit the uplink rate to the uplink bitrate of the plan's channel set. Requires --plan-id.")
+	cmd.Flags().IntVarP(&f.CommandQueueDepth, "command-queue-depth", "", defaultCommandQueueDepth,
+		"The maximum number of queued commands when a command rate is set. Commands are dropped when the queue is full.")
+}
+
+// Validate flag values.
+func (f *CommandQueueFlags) Validate() error {
+	if f.CommandRate < 0 {
+		return fmt.Errorf("invalid value of command rate: %v. Expected a positive value", f.CommandRate)
+	}
+	if f.CommandQueueDepth <= 0 {
+		return fmt.Errorf("invalid value of command queue depth: %v. Expected a positive value", f.CommandQueueDepth)
+	}
+
+	return nil
+}
+
+// Return true when commands are rate limited.
+func (f *CommandQueueFlags) IsEnabled() bool {
+	return f.CommandRate > 0 || f.CommandBitrate > 0 || f.CommandBitrateFromPlan
+}
+
+// Return the command queue options, or nil when commands are not rate limited. planBitrate is the uplink bitrate
+// of the plan's channel set, used when the bitrate is taken from the plan.
+func (f *CommandQueueFlags) ToCommandQueueOptions(planBitrate uint64) *stream.CommandQueueOptions {
+	if !f.IsEnabled() {
+		return nil
+	}
+
+	bitrate := f.CommandBitrate
+	if f.CommandBitrateFromPlan && (bitrate == 0 || planBitrate < bitrate) {
+		bitrate = planBitrate
+	}
+
+	return &stream.CommandQueueOptions{
+		CommandsPerSecond: f.CommandRate,
+		BitsPerSecond:     bitrate,
+		MaxDepth:          f.CommandQueueDepth,
+	}
+}
+
+// Create a new CommandQueueFlags with default values set.
+func NewCommandQueueFlags() *CommandQueueFlags {
+	return &CommandQueueFlags{
+		CommandQueueDepth: defaultCommandQueueDepth,
+	}
+}
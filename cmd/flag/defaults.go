@@ -0,0 +1,118 @@
+//
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+// Flags that never take a default from the environment or the configuration.
+var flagsWithoutDefaults = []string{"help", "profile"}
+
+// CommandPath returns the path of the command below the root command, e.g. ["satellite", "open-stream"].
+func CommandPath(cmd *cobra.Command) []string {
+	path := strings.Fields(cmd.CommandPath())
+	return path[1:]
+}
+
+// AcceptsDefault returns whether the flag with the given name can take a default from the environment or the
+// configuration.
+func AcceptsDefault(name string) bool {
+	return !util.Contains(flagsWithoutDefaults, name)
+}
+
+// SkipDefaultsAnnotation marks a command whose sub commands take no defaults, so that they work while the
+// configuration cannot be loaded. The profile given with --profile is still selected.
+const SkipDefaultsAnnotation = "stellar-skip-defaults"
+
+// Select the profile given with --profile, if any.
+func selectProfile(cmd *cobra.Command) error {
+	if profile, err := cmd.Flags().GetString("profile"); err == nil && profile != "" {
+		if err := config.ValidateProfileName(profile); err != nil {
+			return util.NewUsageError(err)
+		}
+		config.SelectProfile(profile)
+	}
+	return nil
+}
+
+// ApplyDefaults sets the flags that were not given on the command line from the environment, the profile in use and
+// config.yaml, in that order. Flags given nowhere keep their built-in default. Invalid values are usage errors; a
+// configuration that cannot be loaded is not.
+func ApplyDefaults(cmd *cobra.Command) error {
+	if err := selectProfile(cmd); err != nil {
+		return err
+	}
+
+	defaults, err := config.LoadDefaults()
+	if err != nil {
+		return fmt.Errorf("could not load flag defaults, repair or remove the file or use 'stellar config': %w", err)
+	}
+
+	path := CommandPath(cmd)
+	var setErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if setErr != nil || f.Changed || !AcceptsDefault(f.Name) {
+			return
+		}
+		value, source, ok := defaults.Lookup(path, f.Name)
+		if !ok {
+			return
+		}
+		// Setting the value directly keeps the flag unchanged, as if the built-in default was different.
+		if err := f.Value.Set(value); err != nil {
+			setErr = util.NewUsageError(fmt.Errorf("invalid value %q for --%s from %s: %v", value, f.Name, source, err))
+		}
+	})
+	return setErr
+}
+
+// EnableDefaults makes the command and all its sub commands apply the defaults from the environment, the profile and
+// config.yaml before their arguments and flags are validated. Invalid arguments are reported as usage errors.
+// Commands below one annotated with SkipDefaultsAnnotation only select the profile.
+func EnableDefaults(cmd *cobra.Command) {
+	enableDefaults(cmd, ApplyDefaults)
+}
+
+func enableDefaults(cmd *cobra.Command, apply func(cmd *cobra.Command) error) {
+	if _, ok := cmd.Annotations[SkipDefaultsAnnotation]; ok {
+		apply = selectProfile
+	}
+	for _, c := range cmd.Commands() {
+		enableDefaults(c, apply)
+	}
+	if !cmd.Runnable() {
+		return
+	}
+
+	args := cmd.Args
+	cmd.Args = func(cmd *cobra.Command, a []string) error {
+		if err := apply(cmd); err != nil {
+			return err
+		}
+		if args == nil {
+			return nil
+		}
+		return util.NewUsageError(args(cmd, a))
+	}
+}
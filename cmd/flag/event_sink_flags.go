@@ -0,0 +1,85 @@
+//
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
+)
+
+type EventSinkFlags struct {
+	EventFile string
+	EventAddr []string
+}
+
+// Add flags to the command.
+func (f *EventSinkFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.EventFile, "event-file", "", "",
+		"The file to append antenna, receiver and transmitter events to as JSON Lines. (default none)")
+	cmd.Flags().StringSliceVarP(&f.EventAddr, "event-addr", "", nil,
+		"Address to deliver antenna, receiver and transmitter events to as JSON. "+
+			"udp://host:port sends datagrams to the address, tcp://host:port listens for clients on it. (default none)")
+}
+
+// Validate flag values.
+func (f *EventSinkFlags) Validate() error {
+	for _, addr := range f.EventAddr {
+		if !strings.HasPrefix(addr, "udp://") && !strings.HasPrefix(addr, "tcp://") {
+			return fmt.Errorf("invalid event address: %v. Expected udp://host:port or tcp://host:port", addr)
+		}
+	}
+
+	return nil
+}
+
+// Return the event sinks configured by the flags.
+func (f *EventSinkFlags) ToEventSinks() ([]stream.EventSink, error) {
+	var sinks []stream.EventSink
+
+	closeAll := func() {
+		for _, sink := range sinks {
+			_ = sink.Close()
+		}
+	}
+
+	if f.EventFile != "" {
+		sink, err := stream.NewFileEventSink(f.EventFile)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	for _, addr := range f.EventAddr {
+		sink, err := stream.NewEventSinkFromURL(addr)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, nil
+}
+
+// Create a new EventSinkFlags.
+func NewEventSinkFlags() *EventSinkFlags {
+	return &EventSinkFlags{}
+}
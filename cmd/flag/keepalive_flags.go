@@ -0,0 +1,59 @@
+//
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
+)
+
+// KeepaliveFlags holds the keepalive settings of the API connection. They apply to every command.
+type KeepaliveFlags struct {
+	Time    time.Duration
+	Timeout time.Duration
+}
+
+// Add flags to the command and all its sub commands.
+func (f *KeepaliveFlags) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().DurationVar(&f.Time, "keepalive-time", apiclient.DefaultKeepaliveTime,
+		"Time without activity on the API connection after which it is checked with a ping. 0 disables the pings. "+
+			"The API server may close connections that ping more often than it allows.")
+	cmd.PersistentFlags().DurationVar(&f.Timeout, "keepalive-timeout", apiclient.DefaultKeepaliveTimeout,
+		"Time to wait for the response to a keepalive ping before the API connection is considered dead.")
+}
+
+// Validate flag values.
+func (f *KeepaliveFlags) Validate() error {
+	if f.Time < 0 || f.Timeout < 0 {
+		return fmt.Errorf("--keepalive-time and --keepalive-timeout must not be negative")
+	}
+	if f.Time > 0 && f.Time < 10*time.Second {
+		return fmt.Errorf("--keepalive-time must be at least 10s")
+	}
+	return nil
+}
+
+// Create a new KeepaliveFlags with default values set.
+func NewKeepaliveFlags() *KeepaliveFlags {
+	return &KeepaliveFlags{
+		Time:    apiclient.DefaultKeepaliveTime,
+		Timeout: apiclient.DefaultKeepaliveTimeout,
+	}
+}
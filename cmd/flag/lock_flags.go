@@ -0,0 +1,60 @@
+//
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/pkg/lock"
+)
+
+type LockFlags struct {
+	Lock    bool
+	LockDir string
+}
+
+// Add flags to the command.
+func (f *LockFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.Lock, "lock", false,
+		"Fail if another stellar process using a lock streams from the same satellite, or reserves the same pass. "+
+			"Locks are released when their process exits.")
+	cmd.Flags().StringVar(&f.LockDir, "lock-dir", "",
+		"Directory of the lock files, shared by the processes to exclude each other. Implies --lock. (default "+
+			"locks in the configuration directory)")
+}
+
+// Validate flag values.
+func (f *LockFlags) Validate() error {
+	return nil
+}
+
+// Acquire the named lock, or return nil when locking is disabled. The lock is released with Release.
+func (f *LockFlags) ToLock(name string) (*lock.Lock, error) {
+	if !f.Lock && f.LockDir == "" {
+		return nil, nil
+	}
+
+	dir := f.LockDir
+	if dir == "" {
+		dir = lock.DefaultDir()
+	}
+	return lock.Acquire(dir, name)
+}
+
+// Create a new LockFlags with default values set.
+func NewLockFlags() *LockFlags {
+	return &LockFlags{}
+}
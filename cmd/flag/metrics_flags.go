@@ -0,0 +1,61 @@
+//
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
+)
+
+type MetricsFlags struct {
+	MetricsAddr string
+}
+
+// Add flags to the command.
+func (f *MetricsFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.MetricsAddr, "metrics-addr", "", "",
+		"The address to serve Prometheus metrics of the stream on, e.g. :9100. Metrics are served at /metrics. (default none)")
+}
+
+// Validate flag values.
+func (f *MetricsFlags) Validate() error {
+	if f.MetricsAddr == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(f.MetricsAddr); err != nil {
+		return fmt.Errorf("invalid metrics address: %v. Expected host:port or :port", f.MetricsAddr)
+	}
+
+	return nil
+}
+
+// Return the metrics exporter configured by the flags, or nil when metrics are not served.
+func (f *MetricsFlags) ToMetricsExporter() (*stream.MetricsExporter, error) {
+	if f.MetricsAddr == "" {
+		return nil, nil
+	}
+
+	return stream.NewMetricsExporter(f.MetricsAddr)
+}
+
+// Create a new MetricsFlags with default values set.
+func NewMetricsFlags() *MetricsFlags {
+	return &MetricsFlags{}
+}
@@ -0,0 +1,80 @@
+//
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
+)
+
+var (
+	// Supported pass summary formats.
+	availableReportFormats = []string{stream.PassSummaryJSON, stream.PassSummaryYAML}
+	// Default pass summary format.
+	defaultReportFormat = stream.PassSummaryJSON
+)
+
+type PassSummaryFlags struct {
+	ReportDir    string
+	ReportFormat string
+}
+
+// Add flags to the command.
+func (f *PassSummaryFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.ReportDir, "report-dir", "", "",
+		"The directory to write a summary of every plan to when the plan changes or the stream closes. (default none)")
+	cmd.Flags().StringVarP(&f.ReportFormat, "report-format", "", defaultReportFormat,
+		"Format of the plan summaries. One of: "+strings.Join(availableReportFormats, "|"))
+}
+
+// Validate flag values.
+func (f *PassSummaryFlags) Validate() error {
+	if !util.Contains(availableReportFormats, util.ToLower(f.ReportFormat)) {
+		return fmt.Errorf("invalid report format: %v. Expected one of: %v", f.ReportFormat,
+			strings.Join(availableReportFormats, "|"))
+	}
+
+	return nil
+}
+
+// Return the pass summary options, or nil when no summaries are written. The report directory is created if it
+// does not exist.
+func (f *PassSummaryFlags) ToPassSummaryOptions() (*stream.PassSummaryOptions, error) {
+	if f.ReportDir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(f.ReportDir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &stream.PassSummaryOptions{
+		Dir:    f.ReportDir,
+		Format: util.ToLower(f.ReportFormat),
+	}, nil
+}
+
+// Create a new PassSummaryFlags with default values set.
+func NewPassSummaryFlags() *PassSummaryFlags {
+	return &PassSummaryFlags{
+		ReportFormat: defaultReportFormat,
+	}
+}
@@ -16,7 +16,6 @@ package flag
 
 import (
 	"fmt"
-	"log"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -90,7 +89,7 @@ func (f *ProxyFlags) Validate() error {
 }
 
 // Return a Proxy corresponding to the protocol.
-func (f *ProxyFlags) ToProxy() stream.Proxy {
+func (f *ProxyFlags) ToProxy() (stream.Proxy, error) {
 	protocol := util.ToLower(f.ProxyProtocol)
 
 	switch protocol {
@@ -104,9 +103,9 @@ func (f *ProxyFlags) ToProxy() stream.Proxy {
 		}
 		p, err := stream.NewUDPProxy(o)
 		if err != nil {
-			log.Fatalf("could not open UDP proxy: %v\n", err)
+			return nil, fmt.Errorf("could not open UDP proxy: %w", err)
 		}
-		return p
+		return p, nil
 	case "tcp":
 		addr := fmt.Sprintf("%s:%d", f.TCPListenHost, f.TCPListenPort)
 		o := &stream.TCPProxyOptions{
@@ -114,19 +113,18 @@ func (f *ProxyFlags) ToProxy() stream.Proxy {
 		}
 		p, err := stream.NewTCPProxy(o)
 		if err != nil {
-			log.Fatalf("could not open TCP proxy: %v\n", err)
+			return nil, fmt.Errorf("could not open TCP proxy: %w", err)
 		}
-		return p
+		return p, nil
 	case "disabled":
 		p, err := stream.NewConnectionWithoutProxy()
 		if err != nil {
-			log.Fatalf("could not open connection: %v\n", err)
+			return nil, fmt.Errorf("could not open connection: %w", err)
 		}
-		return p
+		return p, nil
 	}
 
-	log.Fatalf("unsupported proxy protocol: %v", protocol)
-	return nil
+	return nil, fmt.Errorf("unsupported proxy protocol: %v", protocol)
 }
 
 // Create a new ProxyFlags with default values set.
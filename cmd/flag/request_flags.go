@@ -0,0 +1,59 @@
+//
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
+)
+
+// RequestFlags holds the deadline and retry policy of API requests. They apply to every command.
+type RequestFlags struct {
+	Timeout time.Duration
+	Retries int
+}
+
+// Add flags to the command and all its sub commands.
+func (f *RequestFlags) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().DurationVar(&f.Timeout, "timeout", apiclient.DefaultTimeout,
+		"Deadline of each API request, including retries. 0 waits forever. Does not apply to streams.")
+	cmd.PersistentFlags().IntVar(&f.Retries, "retries", apiclient.DefaultRetries,
+		"Number of times a read-only API request is retried while the API is unavailable. Requests that change "+
+			"something, like reserving a pass, are never retried.")
+}
+
+// Validate flag values.
+func (f *RequestFlags) Validate() error {
+	if f.Timeout < 0 {
+		return fmt.Errorf("--timeout must not be negative")
+	}
+	if f.Retries < 0 {
+		return fmt.Errorf("--retries must not be negative")
+	}
+	return nil
+}
+
+// Create a new RequestFlags with default values set.
+func NewRequestFlags() *RequestFlags {
+	return &RequestFlags{
+		Timeout: apiclient.DefaultTimeout,
+		Retries: apiclient.DefaultRetries,
+	}
+}
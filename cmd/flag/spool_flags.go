@@ -0,0 +1,72 @@
+//
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
+)
+
+var (
+	// Default maximum spool size, 1 GiB.
+	defaultSpoolMaxBytes int64 = 1 << 30
+)
+
+type SpoolFlags struct {
+	SpoolDir      string
+	SpoolMaxBytes int64
+}
+
+// Add flags to the command.
+func (f *SpoolFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.SpoolDir, "spool-dir", "", "",
+		"Directory to spool packets to while no proxy client is connected or the UDP destination is unavailable. "+
+			"Spooled packets are replayed in order when a consumer attaches, including after a restart. (default none)")
+	cmd.Flags().Int64VarP(&f.SpoolMaxBytes, "spool-max-bytes", "", defaultSpoolMaxBytes,
+		"The maximum size of the spool in bytes. Packets are dropped when the spool is full.")
+}
+
+// Validate flag values.
+func (f *SpoolFlags) Validate() error {
+	if f.SpoolMaxBytes <= 0 {
+		return fmt.Errorf("invalid value of spool max bytes: %v. Expected a positive value", f.SpoolMaxBytes)
+	}
+
+	return nil
+}
+
+// Return the spool for the satellite, or nil when spooling is disabled.
+func (f *SpoolFlags) ToSpool(satelliteId string) (*stream.Spool, error) {
+	if f.SpoolDir == "" {
+		return nil, nil
+	}
+
+	return stream.OpenSpool(&stream.SpoolOptions{
+		Dir:     f.SpoolDir,
+		Name:    satelliteId,
+		MaxSize: f.SpoolMaxBytes,
+	})
+}
+
+// Create a new SpoolFlags with default values set.
+func NewSpoolFlags() *SpoolFlags {
+	return &SpoolFlags{
+		SpoolMaxBytes: defaultSpoolMaxBytes,
+	}
+}
@@ -15,23 +15,43 @@
 
 package flag
 
-import "github.com/spf13/cobra"
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
+)
 
 type StatsFlag struct {
-	ShowStats bool
+	ShowStats    bool
+	GapThreshold time.Duration
+	NTPServer    string
 }
 
 // Add flags to the command.
 func (f *StatsFlag) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&f.ShowStats, "stats", "", false, "[Alpha feature] Output telemetry stats information and generate pass summaries (default false)")
+	cmd.Flags().DurationVarP(&f.GapThreshold, "gap-threshold", "", stream.DefaultGapThreshold,
+		"The minimum time without telemetry during a plan reported as a reception gap in stats and pass summaries.")
+	cmd.Flags().StringVarP(&f.NTPServer, "ntp-server", "", "",
+		"NTP server, host or host:port, to measure the local clock offset with every "+stream.ClockOffsetInterval.String()+
+			". Telemetry delay in stats, metrics and pass summaries is corrected for the offset. By default, the local clock is used as is.")
 }
 
 // Validate flag values.
 func (f *StatsFlag) Validate() error {
+	if f.GapThreshold <= 0 {
+		return fmt.Errorf("invalid value of gap threshold: %v. Expected a positive duration", f.GapThreshold)
+	}
+
 	return nil
 }
 
 // Create a new StatsFlag.
 func NewStatsFlag() *StatsFlag {
-	return &StatsFlag{}
+	return &StatsFlag{
+		GapThreshold: stream.DefaultGapThreshold,
+	}
 }
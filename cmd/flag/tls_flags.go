@@ -0,0 +1,92 @@
+//
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+// TLSFlags holds the TLS settings for the API connection. They apply to every command and take precedence over the
+// settings of the profile.
+type TLSFlags struct {
+	Settings config.TLSSettings
+}
+
+// Add flags to the command and all its sub commands.
+func (f *TLSFlags) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Var(optionalBoolValue{&f.Settings.Insecure}, "insecure",
+		"Skip verification of the API server certificate. Only for testing against a local server. --insecure=false "+
+			"overrides the setting of the profile. (default false)")
+	cmd.PersistentFlags().Lookup("insecure").NoOptDefVal = "true"
+	cmd.PersistentFlags().StringVar(&f.Settings.CAFile, "ca-file", "",
+		"A PEM file with certificate authorities to trust for the API server, in addition to the system ones.")
+	cmd.PersistentFlags().StringVar(&f.Settings.CertFile, "client-cert", "",
+		"A PEM file with a client certificate to present to the API endpoint. Requires --client-key.")
+	cmd.PersistentFlags().StringVar(&f.Settings.KeyFile, "client-key", "",
+		"A PEM file with the private key of --client-cert.")
+	cmd.PersistentFlags().StringVar(&f.Settings.ServerName, "tls-server-name", "",
+		"The server name to send with SNI and verify the API server certificate against, instead of the host of "+
+			"the endpoint.")
+}
+
+// Validate flag values.
+func (f *TLSFlags) Validate() error {
+	if (f.Settings.CertFile == "") != (f.Settings.KeyFile == "") {
+		return fmt.Errorf("--client-cert and --client-key must be given together")
+	}
+	return nil
+}
+
+// Return the TLS settings given by the flags.
+func (f *TLSFlags) ToTLSSettings() config.TLSSettings {
+	return f.Settings
+}
+
+// A bool flag that stays nil until it is set, on the command line or from a default, so that an explicit false can be
+// told from a flag that was not given.
+type optionalBoolValue struct {
+	v **bool
+}
+
+func (b optionalBoolValue) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*b.v = &v
+	return nil
+}
+
+func (b optionalBoolValue) String() string {
+	if b.v == nil || *b.v == nil {
+		return "false"
+	}
+	return strconv.FormatBool(**b.v)
+}
+
+func (b optionalBoolValue) Type() string {
+	return "bool"
+}
+
+// Create a new TLSFlags.
+func NewTLSFlags() *TLSFlags {
+	return &TLSFlags{}
+}
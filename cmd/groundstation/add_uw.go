@@ -16,12 +16,12 @@ package groundstation
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/spf13/cobra"
 
 	"github.com/infostellarinc/stellarcli/cmd/flag"
 	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
 	"github.com/infostellarinc/stellarcli/pkg/groundstation/uw"
 )
 
@@ -53,17 +53,17 @@ func NewAddUWCommand() *cobra.Command {
 
 			return nil
 		},
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			p := outputFormatFlags.ToPrinter()
 
 			startTime, err := util.ParseDateTime(args[1])
 			if err != nil {
-				log.Fatal(err)
+				return util.NewUsageError(err)
 			}
 
 			endTime, err := util.ParseDateTime(args[2])
 			if err != nil {
-				log.Fatal(err)
+				return util.NewUsageError(err)
 			}
 
 			o := &uw.AddUWOptions{
@@ -73,7 +73,10 @@ func NewAddUWCommand() *cobra.Command {
 				EndTime:   endTime,
 			}
 
-			uw.AddUW(o)
+			apiClient := apiclient.NewClient()
+			defer apiClient.Close()
+
+			return uw.AddUW(apiClient, o)
 		},
 	}
 
@@ -21,6 +21,7 @@ import (
 
 	"github.com/infostellarinc/stellarcli/cmd/flag"
 	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
 	"github.com/infostellarinc/stellarcli/pkg/satellite/plan"
 )
 
@@ -50,14 +51,17 @@ func NewCancelPlanCommand() *cobra.Command {
 
 			return nil
 		},
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			p := outputFormatFlags.ToPrinter()
 			o := &plan.CancelPlanOptions{
 				Printer: p,
 				PlanID:  args[0],
 			}
 
-			plan.CancelPlan(o)
+			apiClient := apiclient.NewClient()
+			defer apiClient.Close()
+
+			return plan.CancelPlan(apiClient, o)
 		},
 	}
 
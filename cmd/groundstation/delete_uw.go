@@ -20,6 +20,7 @@ import (
 
 	"github.com/infostellarinc/stellarcli/cmd/flag"
 	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
 	"github.com/infostellarinc/stellarcli/pkg/groundstation/uw"
 )
 
@@ -49,7 +50,7 @@ func NewDeleteUWCommand() *cobra.Command {
 
 			return nil
 		},
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			p := outputFormatFlags.ToPrinter()
 
 			o := &uw.DeleteUWOptions{
@@ -57,7 +58,10 @@ func NewDeleteUWCommand() *cobra.Command {
 				WindowID: args[0],
 			}
 
-			uw.DeleteUW(o)
+			apiClient := apiclient.NewClient()
+			defer apiClient.Close()
+
+			return uw.DeleteUW(apiClient, o)
 		},
 	}
 
@@ -21,6 +21,7 @@ import (
 
 	"github.com/infostellarinc/stellarcli/cmd/flag"
 	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
 	"github.com/infostellarinc/stellarcli/pkg/groundstation/uw"
 )
 
@@ -53,7 +54,7 @@ func NewListUWCommand() *cobra.Command {
 
 			return nil
 		},
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			p := outputFormatFlags.ToPrinter()
 			o := &uw.ListUWOptions{
 				Printer:   p,
@@ -62,7 +63,10 @@ func NewListUWCommand() *cobra.Command {
 				EndTime:   uwRangeFlags.EndTime,
 			}
 
-			uw.ListUW(o)
+			apiClient := apiclient.NewClient()
+			defer apiClient.Close()
+
+			return uw.ListUW(apiClient, o)
 		},
 	}
 
@@ -7,6 +7,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
+	"github.com/infostellarinc/stellarcli/cmd/flag"
 	"github.com/infostellarinc/stellarcli/cmd/util"
 	"github.com/infostellarinc/stellarcli/pkg/apiclient"
 	"github.com/spf13/cobra"
@@ -21,6 +22,8 @@ var (
 
 // Create reserve-pass command.
 func NewInteractiveCommand() *cobra.Command {
+	auditLogFlags := flag.NewAuditLogFlags()
+
 	command := &cobra.Command{
 		Use:   interactiveUse,
 		Short: interactiveShort,
@@ -30,30 +33,35 @@ func NewInteractiveCommand() *cobra.Command {
 				return fmt.Errorf("accepts 1 arg(s), received %d", len(args))
 			}
 
-			return nil
+			return auditLogFlags.Validate()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			debugMode, _ := cmd.PersistentFlags().GetBool("debug")
 
 			log.Printf("Fetching up coming and recent plans for Satellite '%s'.", args[0])
-			conn, err := apiclient.Dial()
+			apiClient := apiclient.NewClient()
+			defer apiClient.Close()
+
+			client, err := apiClient.Service()
 			if err != nil {
-				// don't return an error as it's not a CLI error
-				log.Printf("problem dialing: %v\n", err)
-				return nil
+				return err
 			}
 
-			client := stellarstation.NewStellarStationServiceClient(conn)
-
 			plansResponse, err := client.ListPlans(cmd.Context(), &stellarstation.ListPlansRequest{
 				SatelliteId: args[0],
 				AosAfter:    timestamppb.New(time.Now().Add(-60 * time.Minute)),
 				AosBefore:   timestamppb.New(time.Now().Add(30 * time.Minute)),
 			})
 			if err != nil {
-				log.Printf("could not retrieve plans for Satellite '%s': %v\n", args[0], err)
-				// don't return an error as it's not a CLI error
-				return nil
+				return fmt.Errorf("could not retrieve plans for Satellite '%s': %w", args[0], err)
+			}
+
+			auditLog, err := auditLogFlags.ToCommandAuditLog()
+			if err != nil {
+				return fmt.Errorf("could not open audit log: %w", err)
+			}
+			if auditLog != nil {
+				defer auditLog.Close()
 			}
 
 			var selectedPlan *stellarstation.Plan
@@ -86,6 +94,7 @@ func NewInteractiveCommand() *cobra.Command {
 				client,
 				selectedPlan,
 				debugMode,
+				auditLog,
 			)
 			p := tea.NewProgram(model)
 			if _, err := p.Run(); err != nil {
@@ -95,6 +104,8 @@ func NewInteractiveCommand() *cobra.Command {
 		},
 	}
 
+	auditLogFlags.AddFlags(command)
+
 	return command
 
 }
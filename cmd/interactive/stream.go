@@ -9,6 +9,9 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 )
@@ -25,6 +28,7 @@ func configurationChange(
 		return errMsg{err: errors.New("stream client not active")}
 	}
 
+	sentAt := time.Now()
 	err := STREAM_CLIENT.Send(&stellarstation.SatelliteStreamRequest{
 		SatelliteId:     m.plan.GetSatelliteId(),
 		PlanId:          m.plan.GetId(),
@@ -34,6 +38,9 @@ func configurationChange(
 			GroundStationConfigurationRequest: req,
 		},
 	})
+	if auditErr := auditConfigurationChange(req, m, sentAt, err); auditErr != nil {
+		return errMsg{fmt.Errorf("could not write audit log: %w", auditErr)}
+	}
 	if err != nil {
 		return errMsg{fmt.Errorf("could not send configuration change: %w", err)}
 	}
@@ -41,6 +48,32 @@ func configurationChange(
 	return configurationChangeSent(fmt.Sprintf("configuration change: %v", debugMsg))
 }
 
+// record a configuration change in the audit log, if any
+func auditConfigurationChange(
+	req *stellarstation.GroundStationConfigurationRequest,
+	m model,
+	sentAt time.Time,
+	sendErr error,
+) error {
+	if m.auditLog == nil {
+		return nil
+	}
+
+	payload, err := proto.Marshal(protoadapt.MessageV2Of(req))
+	if err != nil {
+		return err
+	}
+
+	return m.auditLog.Record(&stream.CommandAuditRecord{
+		Time:        sentAt,
+		Kind:        stream.AuditKindConfiguration,
+		SatelliteID: m.plan.GetSatelliteId(),
+		PlanID:      m.plan.GetId(),
+		StreamID:    m.streamID,
+		Source:      "tui:" + m.lastKey,
+	}, payload, sendErr)
+}
+
 func idlePattern(enable bool, m model) tea.Msg {
 	debugMsg := "idle pattern "
 	if enable {
@@ -14,6 +14,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
 	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
+	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
 )
 
 type model struct {
@@ -35,6 +36,8 @@ type model struct {
 	streamClosed      bool
 	streamError       error
 	lastCommandSentAt time.Time
+	lastKey           string
+	auditLog          *stream.CommandAuditLog
 
 	help       help.Model
 	helpKeyMap helpKeyMap
@@ -52,6 +55,7 @@ func initialModel(
 	client stellarstation.StellarStationServiceClient,
 	plan *stellarstation.Plan,
 	debugMode bool,
+	auditLog *stream.CommandAuditLog,
 ) model {
 	txStateTable := table.New().Width(tableWidth).Headers(
 		"Sweep", "Modulation", "Carrier", "Idle Pattern",
@@ -114,6 +118,8 @@ func initialModel(
 
 		debugMode:     debugMode,
 		debugViewport: debugvp,
+
+		auditLog: auditLog,
 	}
 }
 
@@ -209,6 +215,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			break
 		}
 
+		m.lastKey = msg.String()
 		switch {
 		case key.Matches(msg, m.helpKeyMap.SweepEnable):
 			cmd := func() tea.Msg {
@@ -15,13 +15,21 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
 
 	"github.com/infostellarinc/stellarcli/cmd/auth"
+	"github.com/infostellarinc/stellarcli/cmd/capture"
+	"github.com/infostellarinc/stellarcli/cmd/config"
+	"github.com/infostellarinc/stellarcli/cmd/flag"
 	"github.com/infostellarinc/stellarcli/cmd/groundstation"
 	"github.com/infostellarinc/stellarcli/cmd/interactive"
 	"github.com/infostellarinc/stellarcli/cmd/satellite"
 	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
 )
 
 var (
@@ -32,7 +40,10 @@ var (
 
 		$ stellar auth activate-api-key path/to/stellarstation-private-key.json
 
-		All commands should work after that.`)
+		All commands should work after that. To work with several organisations or endpoints, keep an API key per
+		profile with --profile and switch between them with
+
+		$ stellar auth use-profile [profile]`)
 	stellarShort = util.Normalize("stellar is a command line tool for using the StellarStation API.")
 )
 
@@ -42,10 +53,44 @@ func NewRootCommand() *cobra.Command {
 		Use:   stellarUse,
 		Short: stellarShort,
 		Long:  stellarLong,
+		// main prints the error and exits with the code matching it. Usage is only worth showing for usage errors.
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	command.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return util.NewUsageError(err)
+	})
+
+	command.PersistentFlags().String("profile", "",
+		"The profile to use for credentials, API settings and flag defaults. Overrides STELLAR_PROFILE and the "+
+			"profile set with 'stellar auth use-profile'.")
+
+	// Settings of the API connection, applied to every command.
+	tlsFlags := flag.NewTLSFlags()
+	apiProxyFlag := flag.NewApiProxyFlag()
+	keepaliveFlags := flag.NewKeepaliveFlags()
+	requestFlags := flag.NewRequestFlags()
+	apiFlags := flag.NewFlagSet(tlsFlags, apiProxyFlag, keepaliveFlags, requestFlags)
+	apiFlags.AddAllFlags(command)
+	command.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := apiFlags.ValidateAll(); err != nil {
+			return util.NewUsageError(err)
+		}
+		apiclient.SetDefaultOptions(apiclient.Options{
+			TLS:              tlsFlags.ToTLSSettings(),
+			ProxyURL:         apiProxyFlag.Proxy,
+			KeepaliveTime:    keepaliveFlags.Time,
+			KeepaliveTimeout: keepaliveFlags.Timeout,
+			Timeout:          requestFlags.Timeout,
+			Retries:          requestFlags.Retries,
+		})
+		return nil
 	}
 
 	// Add sub commands
 	command.AddCommand(auth.NewAuthCommand())
+	command.AddCommand(capture.NewCaptureCommand())
+	command.AddCommand(config.NewConfigCommand())
 	command.AddCommand(groundstation.NewGroundStationCommand())
 	command.AddCommand(satellite.NewSatelliteCommand())
 	interactiveCmd := interactive.NewInteractiveCommand()
@@ -53,5 +98,35 @@ func NewRootCommand() *cobra.Command {
 	command.AddCommand(interactiveCmd)
 	command.AddCommand(NewVersionCommand())
 
+	rejectUnknownCommands(command)
+
+	// Apply flag defaults from the environment, the profile and config.yaml to every command.
+	flag.EnableDefaults(command)
+
 	return command
 }
+
+// Make the command and every command grouping sub commands fail with a usage error for an unknown sub command, instead
+// of printing the help and succeeding. Without arguments, they still print the help.
+func rejectUnknownCommands(cmd *cobra.Command) {
+	for _, c := range cmd.Commands() {
+		rejectUnknownCommands(c)
+	}
+	if cmd.Runnable() || !cmd.HasSubCommands() {
+		return
+	}
+
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return nil
+		}
+		message := fmt.Sprintf("unknown command %q for %q", args[0], cmd.CommandPath())
+		if suggestions := cmd.SuggestionsFor(args[0]); len(suggestions) > 0 {
+			message += fmt.Sprintf(", did you mean %s?", strings.Join(suggestions, " or "))
+		}
+		return errors.New(message)
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	}
+}
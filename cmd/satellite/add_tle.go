@@ -21,6 +21,7 @@ import (
 
 	"github.com/infostellarinc/stellarcli/cmd/flag"
 	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
 	"github.com/infostellarinc/stellarcli/pkg/satellite/tle"
 )
 
@@ -50,7 +51,7 @@ func NewAddTLECommand() *cobra.Command {
 
 			return nil
 		},
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			p := outputFormatFlags.ToPrinter()
 
 			o := &tle.AddTLEOptions{
@@ -60,7 +61,10 @@ func NewAddTLECommand() *cobra.Command {
 				Line2:       args[2],
 			}
 
-			tle.AddTLE(o)
+			apiClient := apiclient.NewClient()
+			defer apiClient.Close()
+
+			return tle.AddTLE(apiClient, o)
 		},
 	}
 
@@ -21,6 +21,7 @@ import (
 
 	"github.com/infostellarinc/stellarcli/cmd/flag"
 	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
 	"github.com/infostellarinc/stellarcli/pkg/satellite/tle"
 )
 
@@ -50,14 +51,17 @@ func NewGetTLECommand() *cobra.Command {
 
 			return nil
 		},
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			p := outputFormatFlags.ToPrinter()
 			o := &tle.GetTLEOptions{
 				Printer:     p,
 				SatelliteId: args[0],
 			}
 
-			tle.GetTLE(o)
+			apiClient := apiclient.NewClient()
+			defer apiClient.Close()
+
+			return tle.GetTLE(apiClient, o)
 		},
 	}
 
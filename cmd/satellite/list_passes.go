@@ -21,6 +21,7 @@ import (
 
 	"github.com/infostellarinc/stellarcli/cmd/flag"
 	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
 	"github.com/infostellarinc/stellarcli/pkg/satellite/pass"
 )
 
@@ -51,7 +52,7 @@ func NewListAvailablePassesCommand() *cobra.Command {
 
 			return nil
 		},
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			p := outputFormatFlags.ToPrinter(verboseFlag.IsVerbose)
 			o := &pass.ListAvailablePassesOptions{
 				Printer:      p,
@@ -60,7 +61,10 @@ func NewListAvailablePassesCommand() *cobra.Command {
 				IsVerbose:    verboseFlag.IsVerbose,
 			}
 
-			pass.ListAvailablePasses(o)
+			apiClient := apiclient.NewClient()
+			defer apiClient.Close()
+
+			return pass.ListAvailablePasses(apiClient, o)
 		},
 	}
 
@@ -19,6 +19,7 @@ import (
 
 	"github.com/infostellarinc/stellarcli/cmd/flag"
 	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
 	"github.com/infostellarinc/stellarcli/pkg/satellite/plan"
 
 	"github.com/spf13/cobra"
@@ -53,7 +54,7 @@ func NewListPlansCommand() *cobra.Command {
 
 			return nil
 		},
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			p := outputFormatFlags.ToPrinter(verboseFlag.IsVerbose)
 			o := &plan.ListOptions{
 				Printer:   p,
@@ -63,7 +64,10 @@ func NewListPlansCommand() *cobra.Command {
 				IsVerbose: verboseFlag.IsVerbose,
 			}
 
-			plan.ListPlans(o)
+			apiClient := apiclient.NewClient()
+			defer apiClient.Close()
+
+			return plan.ListPlans(apiClient, o)
 		},
 	}
 
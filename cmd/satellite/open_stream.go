@@ -20,8 +20,12 @@ import (
 	"os"
 	"os/signal"
 
+	"github.com/infostellarinc/stellarcli/cmd/dashboard"
 	"github.com/infostellarinc/stellarcli/cmd/flag"
 	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
+	"github.com/infostellarinc/stellarcli/pkg/lock"
+	"github.com/infostellarinc/stellarcli/pkg/satellite/plan"
 	"github.com/infostellarinc/stellarcli/pkg/satellite/stream"
 	"github.com/spf13/cobra"
 )
@@ -38,17 +42,27 @@ var (
 
 // Create open-stream command.
 func NewOpenStreamCommand() *cobra.Command {
+	alertFlags := flag.NewAlertFlags()
+	auditLogFlags := flag.NewAuditLogFlags()
+	captureFlags := flag.NewCaptureFlags()
+	commandQueueFlags := flag.NewCommandQueueFlags()
 	debugFlag := flag.NewDebugFlag()
 	correctOrderFlags := flag.NewCorrectOrderFlags()
+	dashboardFlag := flag.NewDashboardFlag()
+	eventSinkFlags := flag.NewEventSinkFlags()
 	framingFlags := flag.NewFramingFlags()
 	groundStationIdFlag := flag.NewGroundStationIdFlag()
+	lockFlags := flag.NewLockFlags()
+	metricsFlags := flag.NewMetricsFlags()
 	openStreamFlag := flag.NewOpenStreamFlag()
+	passSummaryFlags := flag.NewPassSummaryFlags()
 	planIdFlag := flag.NewPlanIdFlag()
 	proxyFlags := flag.NewProxyFlags()
+	spoolFlags := flag.NewSpoolFlags()
 	verboseFlag := flag.NewVerboseFlags()
 	statsFlag := flag.NewStatsFlag()
 	writeFileFlag := flag.NewWriteFileFlag()
-	flags := flag.NewFlagSet(correctOrderFlags, debugFlag, framingFlags, groundStationIdFlag, openStreamFlag, planIdFlag, proxyFlags, verboseFlag, statsFlag, writeFileFlag)
+	flags := flag.NewFlagSet(alertFlags, auditLogFlags, captureFlags, commandQueueFlags, correctOrderFlags, dashboardFlag, debugFlag, eventSinkFlags, framingFlags, groundStationIdFlag, lockFlags, metricsFlags, openStreamFlag, passSummaryFlags, planIdFlag, proxyFlags, spoolFlags, verboseFlag, statsFlag, writeFileFlag)
 
 	command := &cobra.Command{
 		Use:   openStreamUse,
@@ -63,12 +77,106 @@ func NewOpenStreamCommand() *cobra.Command {
 				return err
 			}
 
+			if commandQueueFlags.CommandBitrateFromPlan && planIdFlag.PlanId == "" {
+				return fmt.Errorf("--command-bitrate-from-plan requires --plan-id")
+			}
+
+			if dashboardFlag.Dashboard && statsFlag.ShowStats {
+				// A default from the environment or config.yaml gives way to the other flag given on the command line.
+				switch {
+				case cmd.Flags().Changed("dashboard") && !cmd.Flags().Changed("stats"):
+					statsFlag.ShowStats = false
+				case cmd.Flags().Changed("stats") && !cmd.Flags().Changed("dashboard"):
+					dashboardFlag.Dashboard = false
+				default:
+					return fmt.Errorf("--dashboard replaces the output of --stats; use only one of them")
+				}
+			}
+
 			return nil
 		},
-		Run: func(cmd *cobra.Command, args []string) {
-			proxy := proxyFlags.ToProxy()
+		RunE: func(cmd *cobra.Command, args []string) error {
+			streamLock, err := lockFlags.ToLock(lock.StreamName(args[0]))
+			if err != nil {
+				return err
+			}
+			defer streamLock.Release()
+
+			apiClient := apiclient.NewClient()
+			defer apiClient.Close()
+
+			eventSinks, err := eventSinkFlags.ToEventSinks()
+			if err != nil {
+				return fmt.Errorf("could not open event sink: %w", err)
+			}
+
+			var statsView *stream.StatsView
+			var board *dashboard.Dashboard
+			if dashboardFlag.Dashboard {
+				statsView = stream.NewStatsView()
+				board = dashboard.New(statsView)
+				eventSinks = append(eventSinks, board.EventSink())
+			}
+
+			spool, err := spoolFlags.ToSpool(args[0])
+			if err != nil {
+				return fmt.Errorf("could not open spool: %w", err)
+			}
+			if spool != nil {
+				defer spool.Close()
+			}
+
+			auditLog, err := auditLogFlags.ToCommandAuditLog()
+			if err != nil {
+				return fmt.Errorf("could not open audit log: %w", err)
+			}
+			if auditLog != nil {
+				defer auditLog.Close()
+			}
+
+			capture, err := captureFlags.ToCaptureWriter(args[0])
+			if err != nil {
+				return fmt.Errorf("could not open capture file: %w", err)
+			}
+			if capture != nil {
+				defer capture.Close()
+			}
+
+			exporter, err := metricsFlags.ToMetricsExporter()
+			if err != nil {
+				return fmt.Errorf("could not serve metrics: %w", err)
+			}
+			if exporter != nil {
+				defer exporter.Close()
+			}
+
+			passSummary, err := passSummaryFlags.ToPassSummaryOptions()
+			if err != nil {
+				return fmt.Errorf("could not create report directory: %w", err)
+			}
+
+			var planBitrate uint64
+			if commandQueueFlags.CommandBitrateFromPlan {
+				p, err := plan.GetPlan(apiClient, args[0], planIdFlag.PlanId)
+				if err != nil {
+					return fmt.Errorf("could not get uplink bitrate of the plan: %w", err)
+				}
+				planBitrate = p.GetChannelSet().GetUplink().GetBitrate()
+				if planBitrate == 0 {
+					return fmt.Errorf("plan %s has no uplink bitrate", planIdFlag.PlanId)
+				}
+				log.Printf("limiting uplink to the plan's bitrate: %d bits/s\n", planBitrate)
+			}
+
+			proxy, err := proxyFlags.ToProxy()
+			if err != nil {
+				return err
+			}
 			defer proxy.Close()
 
+			// Receives the error of a stream that ended by itself.
+			ended := make(chan error, 1)
+
 			o := &stream.SatelliteStreamOptions{
 				SatelliteID:     args[0],
 				AcceptedFraming: framingFlags.ToProtoAcceptedFraming(),
@@ -79,32 +187,78 @@ func NewOpenStreamCommand() *cobra.Command {
 				IsVerbose:       verboseFlag.IsVerbose,
 				ShowStats:       statsFlag.ShowStats,
 				TelemetryFile:   writeFileFlag.TelemetryFile,
+				EventSinks:      eventSinks,
+				Spool:           spool,
+				CommandQueue:    commandQueueFlags.ToCommandQueueOptions(planBitrate),
+				AuditLog:        auditLog,
+				MetricsExporter: exporter,
+				PassSummary:     passSummary,
+				GapThreshold:    statsFlag.GapThreshold,
+				NTPServer:       statsFlag.NTPServer,
+				Alerts:          alertFlags.ToAlertOptions(apiClient, args[0]),
+				StatsView:       statsView,
+				Capture:         capture,
+				Client:          apiClient,
 
 				CorrectOrder:   correctOrderFlags.CorrectOrder,
 				DelayThreshold: correctOrderFlags.DelayThreshold,
 
 				EnableAutoClose: openStreamFlag.EnableAutoClose,
+				OnEnd:           func(err error) { ended <- err },
 			}
 
-			if proxyFlags.ProxyProtocol == "disabled" && writeFileFlag.FileName == "" {
+			if proxyFlags.ProxyProtocol == "disabled" && writeFileFlag.FileName == "" && spoolFlags.SpoolDir == "" && captureFlags.CaptureFile == "" {
 				log.Println("No proxy or output file set. Streamed data will be discarded")
 			}
 
 			c := make(chan os.Signal, 1)
 			signal.Notify(c, os.Interrupt)
 			defer close(c)
+			defer signal.Stop(c)
 
 			cleanup, err := proxy.Start(o)
 			if err != nil {
-				log.Fatalf("could not start proxy: %v\n", err)
+				return fmt.Errorf("could not start proxy: %w", err)
 			}
 
-			<-c
+			// Wait until interrupted or until the stream ends by itself.
+			wait := func() error {
+				select {
+				case <-c:
+					return nil
+				case err := <-ended:
+					return err
+				}
+			}
+
+			var streamErr error
+			if board != nil {
+				// Show log output in the event log of the dashboard while it runs.
+				log.SetOutput(board)
+				waited := make(chan error, 1)
+				go func() {
+					waited <- wait()
+					board.Quit()
+				}()
+				err := board.Run()
+				log.SetOutput(os.Stderr)
+				if err != nil {
+					log.Printf("could not run dashboard: %v\n", err)
+				}
+				select {
+				case streamErr = <-waited:
+				default:
+					// The dashboard was quit.
+				}
+			} else {
+				streamErr = wait()
+			}
 
 			if cleanup != nil {
 				cleanup()
 			}
 
+			return streamErr
 		},
 	}
 
@@ -21,6 +21,8 @@ import (
 
 	"github.com/infostellarinc/stellarcli/cmd/flag"
 	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
+	"github.com/infostellarinc/stellarcli/pkg/lock"
 	"github.com/infostellarinc/stellarcli/pkg/satellite/pass"
 )
 
@@ -32,8 +34,9 @@ var (
 
 // Create reserve-pass command.
 func NewReservePassCommand() *cobra.Command {
+	lockFlags := flag.NewLockFlags()
 	outputFormatFlags := flag.NewOutputFormatFlags()
-	flags := flag.NewFlagSet(outputFormatFlags)
+	flags := flag.NewFlagSet(lockFlags, outputFormatFlags)
 
 	command := &cobra.Command{
 		Use:   reservePassUse,
@@ -50,14 +53,23 @@ func NewReservePassCommand() *cobra.Command {
 
 			return nil
 		},
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reservationLock, err := lockFlags.ToLock(lock.ReservationName(args[0]))
+			if err != nil {
+				return err
+			}
+			defer reservationLock.Release()
+
 			p := outputFormatFlags.ToPrinter()
 			o := &pass.ReservePassOptions{
 				Printer:          p,
 				ReservationToken: args[0],
 			}
 
-			pass.ReservePass(o)
+			apiClient := apiclient.NewClient()
+			defer apiClient.Close()
+
+			return pass.ReservePass(apiClient, o)
 		},
 	}
 
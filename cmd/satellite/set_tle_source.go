@@ -21,6 +21,7 @@ import (
 
 	"github.com/infostellarinc/stellarcli/cmd/flag"
 	"github.com/infostellarinc/stellarcli/cmd/util"
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
 	"github.com/infostellarinc/stellarcli/pkg/satellite/tle"
 )
 
@@ -50,7 +51,7 @@ func NewSetTLESourceCommand() *cobra.Command {
 
 			return nil
 		},
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			p := outputFormatFlags.ToPrinter()
 
 			o := &tle.SetTLESourceOptions{
@@ -59,7 +60,10 @@ func NewSetTLESourceCommand() *cobra.Command {
 				Source:      args[1],
 			}
 
-			tle.SetTLESource(o)
+			apiClient := apiclient.NewClient()
+			defer apiClient.Close()
+
+			return tle.SetTLESource(apiClient, o)
 		},
 	}
 
@@ -0,0 +1,97 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/infostellarinc/stellarcli/pkg/auth"
+)
+
+// Exit codes of the stellar command. They are listed in the README; keep both in sync.
+const (
+	ExitOK               = 0
+	ExitError            = 1
+	ExitUsage            = 2
+	ExitAuth             = 3
+	ExitNotFound         = 4
+	ExitPermissionDenied = 5
+	ExitNetwork          = 6
+	ExitServer           = 7
+	ExitRejected         = 8
+)
+
+// UsageError is an error in the arguments or flags of a command.
+type UsageError struct {
+	Err error
+}
+
+// NewUsageError wraps the error as a usage error. A nil error stays nil.
+func NewUsageError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &UsageError{Err: err}
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code for the error returned by a command.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var usageErr *UsageError
+	if errors.As(err, &usageErr) {
+		return ExitUsage
+	}
+	var credentialsErr *auth.CredentialsError
+	if errors.As(err, &credentialsErr) {
+		return ExitAuth
+	}
+
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &grpcErr) {
+		return ExitError
+	}
+	switch grpcErr.GRPCStatus().Code() {
+	case codes.OK:
+		return ExitOK
+	case codes.Unauthenticated:
+		return ExitAuth
+	case codes.NotFound:
+		return ExitNotFound
+	case codes.PermissionDenied:
+		return ExitPermissionDenied
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition, codes.AlreadyExists:
+		return ExitRejected
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return ExitNetwork
+	case codes.Canceled:
+		return ExitError
+	default:
+		return ExitServer
+	}
+}
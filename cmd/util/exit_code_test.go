@@ -0,0 +1,56 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/infostellarinc/stellarcli/pkg/auth"
+)
+
+var _ = Describe("ExitCode", func() {
+
+	It("maps usage and credentials errors", func() {
+		Expect(ExitCode(nil)).To(Equal(ExitOK))
+		Expect(ExitCode(errors.New("disk full"))).To(Equal(ExitError))
+		Expect(NewUsageError(nil)).To(BeNil())
+		Expect(ExitCode(NewUsageError(errors.New("accepts 1 arg(s), received 0")))).To(Equal(ExitUsage))
+		Expect(ExitCode(&auth.CredentialsError{Err: errors.New("no API key activated")})).To(Equal(ExitAuth))
+	})
+
+	It("maps gRPC status codes, also when wrapped", func() {
+		expected := map[codes.Code]int{
+			codes.Unauthenticated:  ExitAuth,
+			codes.NotFound:         ExitNotFound,
+			codes.PermissionDenied: ExitPermissionDenied,
+			codes.InvalidArgument:  ExitRejected,
+			codes.AlreadyExists:    ExitRejected,
+			codes.Unavailable:      ExitNetwork,
+			codes.DeadlineExceeded: ExitNetwork,
+			codes.Internal:         ExitServer,
+			codes.Unknown:          ExitServer,
+		}
+		for code, exitCode := range expected {
+			err := fmt.Errorf("error listing plans: %w", status.Error(code, "failed"))
+			Expect(ExitCode(err)).To(Equal(exitCode), code.String())
+		}
+	})
+})
@@ -15,15 +15,24 @@
 package main
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"os"
 
 	"github.com/infostellarinc/stellarcli/cmd"
+	"github.com/infostellarinc/stellarcli/cmd/util"
 )
 
 func main() {
 	// Execute root command.
-	cmd := cmd.NewRootCommand()
-	if err := cmd.Execute(); err != nil {
-		log.Fatal(err)
+	root := cmd.NewRootCommand()
+	command, err := root.ExecuteC()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		var usageErr *util.UsageError
+		if errors.As(err, &usageErr) {
+			fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", command.CommandPath())
+		}
+		os.Exit(util.ExitCode(err))
 	}
 }
@@ -0,0 +1,236 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
+	"github.com/infostellarinc/go-stellarstation/api/v1/groundstation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/infostellarinc/stellarcli/pkg/auth"
+	"github.com/infostellarinc/stellarcli/pkg/config"
+	log "github.com/infostellarinc/stellarcli/pkg/logger"
+)
+
+const (
+	// DefaultKeepaliveTime is the default time without activity after which the connection is checked with a ping.
+	// It is the shortest interval gRPC servers accept by default.
+	DefaultKeepaliveTime = 5 * time.Minute
+	// DefaultKeepaliveTimeout is the default time to wait for the ping response before the connection is closed.
+	DefaultKeepaliveTimeout = 20 * time.Second
+)
+
+// ErrClientClosed is returned when a closed client is used.
+var ErrClientClosed = errors.New("API client is closed")
+
+// Options configures the connection of a Client.
+type Options struct {
+	// TLS settings given on the command line. Settings not given are taken from the profile in use.
+	TLS config.TLSSettings
+	// The proxy to connect through. Empty means the proxy is taken from HTTPS_PROXY and NO_PROXY.
+	ProxyURL string
+	// Time without activity after which the connection is checked with a ping. Zero disables keepalive pings.
+	KeepaliveTime time.Duration
+	// Time to wait for the ping response before the connection is considered dead.
+	KeepaliveTimeout time.Duration
+	// Deadline of every unary call, including retries. Zero means no deadline. Streams have no deadline.
+	Timeout time.Duration
+	// Number of times an idempotent read is retried while the API is unavailable.
+	Retries int
+}
+
+var defaultOptions = Options{
+	KeepaliveTime:    DefaultKeepaliveTime,
+	KeepaliveTimeout: DefaultKeepaliveTimeout,
+	Timeout:          DefaultTimeout,
+	Retries:          DefaultRetries,
+}
+
+// SetDefaultOptions sets the options of clients created with NewClient, usually from the command line.
+func SetDefaultOptions(o Options) {
+	defaultOptions = o
+}
+
+// Client owns a connection to the StellarStation API, shared by all calls made with it. The connection is dialed on
+// first use. Close the client when it is no longer needed.
+type Client struct {
+	options Options
+
+	mu     sync.Mutex
+	conn   *sharedConn
+	closed bool
+	// The credentials of the API key, resolved on the first dial. A reconnect does not ask for a passphrase or run
+	// the credential helper again.
+	creds credentials.PerRPCCredentials
+}
+
+// sharedConn is a connection of a client. A connection replaced by Reconnect is retired: it is closed once no unary
+// call is in progress on it, so that calls started before the reconnect can complete.
+type sharedConn struct {
+	*grpc.ClientConn
+
+	mu      sync.Mutex
+	calls   int
+	retired bool
+}
+
+// interceptor counts the unary calls in progress on the connection.
+func (s *sharedConn) interceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	s.mu.Lock()
+	s.calls++
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.calls--
+		if s.retired && s.calls == 0 {
+			_ = s.Close()
+		}
+	}()
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// retire closes the connection once the calls in progress on it have completed.
+func (s *sharedConn) retire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retired = true
+	if s.calls == 0 {
+		_ = s.Close()
+	}
+}
+
+// NewClient creates a client with the options set with SetDefaultOptions.
+func NewClient() *Client {
+	return NewClientWithOptions(defaultOptions)
+}
+
+// NewClientWithOptions creates a client with the given options.
+func NewClientWithOptions(o Options) *Client {
+	return &Client{options: o}
+}
+
+// Conn returns the connection of the client, dialing it if needed.
+func (c *Client) Conn() (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, ErrClientClosed
+	}
+	if c.conn != nil {
+		return c.conn.ClientConn, nil
+	}
+	return c.dial()
+}
+
+// Reconnect dials a new connection that replaces the current one, if any. Unary calls in progress on the old
+// connection complete before it is closed; streams on it end.
+func (c *Client) Reconnect() (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, ErrClientClosed
+	}
+	old := c.conn
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	if old != nil {
+		old.retire()
+	}
+	return conn, nil
+}
+
+// Service returns a client of the satellite operator API on the connection of the client.
+func (c *Client) Service() (stellarstation.StellarStationServiceClient, error) {
+	conn, err := c.Conn()
+	if err != nil {
+		return nil, err
+	}
+	return stellarstation.NewStellarStationServiceClient(conn), nil
+}
+
+// GroundStationService returns a client of the ground station operator API on the connection of the client.
+func (c *Client) GroundStationService() (groundstation.GroundStationServiceClient, error) {
+	conn, err := c.Conn()
+	if err != nil {
+		return nil, err
+	}
+	return groundstation.NewGroundStationServiceClient(conn), nil
+}
+
+// Close closes the connection. The client cannot be used afterwards. Close is safe to call on a nil client and more
+// than once.
+func (c *Client) Close() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// Dial a new connection. Must be called with mu held.
+func (c *Client) dial() (*grpc.ClientConn, error) {
+	if c.creds == nil {
+		creds, err := auth.NewDefaultCredentials()
+		if err != nil {
+			return nil, err
+		}
+		c.creds = creds
+	}
+
+	shared := &sharedConn{}
+	conn, err := dial(c.options, c.creds, shared.interceptor)
+	if err != nil {
+		return nil, err
+	}
+	shared.ClientConn = conn
+	c.conn = shared
+	go logStateChanges(conn)
+	return conn, nil
+}
+
+// Log the state changes of the connection until it is closed.
+func logStateChanges(conn *grpc.ClientConn) {
+	state := conn.GetState()
+	for conn.WaitForStateChange(context.Background(), state) {
+		state = conn.GetState()
+		if state == connectivity.Shutdown {
+			return
+		}
+		log.Verbose("API connection: %s\n", state)
+	}
+}
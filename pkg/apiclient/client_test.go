@@ -0,0 +1,172 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// Point the client at a local endpoint with a generated API key.
+func useTestCredentials(t *testing.T) {
+	homedir.DisableCache = true
+	t.Cleanup(func() { homedir.DisableCache = false })
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("STELLAR_PROFILE", "")
+	t.Setenv("STELLARSTATION_API_URL", "127.0.0.1:1")
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "test@example.com",
+		"private_key_id": "key1",
+		"private_key": string(pem.EncodeToMemory(&pem.Block{
+			Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key),
+		})),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("STELLAR_CREDENTIALS", path)
+}
+
+func TestClient(t *testing.T) {
+	useTestCredentials(t)
+
+	client := NewClientWithOptions(Options{KeepaliveTime: DefaultKeepaliveTime, KeepaliveTimeout: DefaultKeepaliveTimeout})
+	conn, err := client.Conn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := client.Conn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != conn {
+		t.Errorf("the connection should be shared")
+	}
+	if _, err := client.Service(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GroundStationService(); err != nil {
+		t.Fatal(err)
+	}
+
+	replaced, err := client.Reconnect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced == conn {
+		t.Errorf("reconnecting should dial a new connection")
+	}
+	if state := conn.GetState(); state != connectivity.Shutdown {
+		t.Errorf("the replaced connection should be closed, state %s", state)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if state := replaced.GetState(); state != connectivity.Shutdown {
+		t.Errorf("closing the client should close the connection, state %s", state)
+	}
+	if _, err := client.Conn(); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("using a closed client: %v", err)
+	}
+	if _, err := client.Reconnect(); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("reconnecting a closed client: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("closing twice: %v", err)
+	}
+
+	var nilClient *Client
+	if err := nilClient.Close(); err != nil {
+		t.Errorf("closing a nil client: %v", err)
+	}
+}
+
+func TestReconnectWithCallInProgress(t *testing.T) {
+	useTestCredentials(t)
+
+	client := NewClientWithOptions(Options{})
+	defer client.Close()
+	conn, err := client.Conn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared := client.conn
+
+	started, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = shared.interceptor(context.Background(), "/test", nil, nil, conn,
+			func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+				close(started)
+				<-release
+				return nil
+			})
+	}()
+	<-started
+
+	if _, err := client.Reconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if state := conn.GetState(); state == connectivity.Shutdown {
+		t.Errorf("the replaced connection should stay open while a call is in progress")
+	}
+
+	close(release)
+	<-done
+	if state := conn.GetState(); state != connectivity.Shutdown {
+		t.Errorf("the replaced connection should be closed after the call, state %s", state)
+	}
+}
+
+func TestReconnectKeepsCredentials(t *testing.T) {
+	useTestCredentials(t)
+
+	client := NewClientWithOptions(Options{})
+	defer client.Close()
+	if _, err := client.Conn(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The key is only read on the first dial.
+	if err := os.Remove(os.Getenv("STELLAR_CREDENTIALS")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Reconnect(); err != nil {
+		t.Errorf("reconnect read the API key again: %v", err)
+	}
+}
@@ -15,45 +15,75 @@
 package apiclient
 
 import (
-	"crypto/tls"
 	"fmt"
 	"os"
-	"strings"
 
+	"golang.org/x/net/http/httpproxy"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 
 	"github.com/infostellarinc/stellarcli/app"
-	"github.com/infostellarinc/stellarcli/pkg/auth"
+	"github.com/infostellarinc/stellarcli/pkg/config"
 	log "github.com/infostellarinc/stellarcli/pkg/logger"
 )
 
-// Dial opens a gRPC connection to the StellarStation API with authentication setup.
-func Dial() (*grpc.ClientConn, error) {
-	creds, err := auth.NewDefaultCredentials()
+// Open a gRPC connection to the StellarStation API authenticated with creds. Unary calls pass through track before
+// the interceptor applying timeouts and retries.
+func dial(o Options, creds credentials.PerRPCCredentials, track grpc.UnaryClientInterceptor) (*grpc.ClientConn, error) {
+	profile, err := config.CurrentProfile()
 	if err != nil {
 		return nil, err
 	}
 
 	apiUrl := os.Getenv("STELLARSTATION_API_URL")
+	if len(apiUrl) == 0 {
+		apiUrl = profile.APIURL
+	}
 	if len(apiUrl) == 0 {
 		apiUrl = "api.stellarstation.com:443"
 	}
-	log.Printf("API endpoint: %s", apiUrl)
+	// Details of the connection are only logged in verbose mode, as every command and every reconnect dials.
+	if profile.Name != config.DefaultProfile {
+		log.Verbose("Profile: %s\n", profile.Name)
+	}
+	log.Verbose("API endpoint: %s\n", apiUrl)
 
-	tlsConfig := &tls.Config{
-		MinVersion: tls.VersionTLS12,
+	tls := o.TLS.Merge(profile.TLS)
+	tlsConfig, mode, err := newTLSConfig(tls)
+	if err != nil {
+		return nil, err
+	}
+	if tls.IsInsecure() {
+		// Always warn when the server is not verified.
+		log.Printf("TLS: %s", mode)
+	} else {
+		log.Verbose("TLS: %s\n", mode)
 	}
-	if strings.HasPrefix(apiUrl, "localhost") || strings.HasPrefix(apiUrl, "127.0.0.1") {
-		tlsConfig.InsecureSkipVerify = true
+
+	dialer, route, err := newDialer(o.ProxyURL, httpproxy.FromEnvironment(), apiUrl)
+	if err != nil {
+		return nil, err
 	}
+	log.Verbose("API connection: %s\n", route)
 
-	return grpc.Dial(
-		apiUrl,
+	dialOptions := []grpc.DialOption{
 		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithContextDialer(dialer),
 		grpc.WithPerRPCCredentials(creds),
 		grpc.WithUserAgent(fmt.Sprintf("stellarcli/%s/%s", app.Version, app.Commit)),
 		// By default, GRPC sets the max message size to 4MB, but StellarStation can support up to 10MB.
 		// If GRPC message would be received which exceeds this GRPC limit, a RESOURCE_EXHAUSTED error will be returned.
-		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(10*1024*1024)))
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(10 * 1024 * 1024)),
+		grpc.WithChainUnaryInterceptor(track, unaryInterceptor(o)),
+	}
+	if o.KeepaliveTime > 0 {
+		log.Verbose("API keepalive: ping after %v idle, timeout %v\n", o.KeepaliveTime, o.KeepaliveTimeout)
+		dialOptions = append(dialOptions, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    o.KeepaliveTime,
+			Timeout: o.KeepaliveTimeout,
+		}))
+	}
+
+	return grpc.Dial(apiUrl, dialOptions...)
 }
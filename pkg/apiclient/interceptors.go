@@ -0,0 +1,164 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	log "github.com/infostellarinc/stellarcli/pkg/logger"
+)
+
+const (
+	// DefaultTimeout is the default deadline of a unary API call, including retries.
+	DefaultTimeout = time.Minute
+	// DefaultRetries is the default number of times an idempotent read is retried when the API is unavailable.
+	DefaultRetries = 3
+)
+
+// Unary calls that only read and can be retried safely.
+var idempotentMethods = map[string]bool{
+	"/stellarstation.api.v1.StellarStationService/GetTle":                                 true,
+	"/stellarstation.api.v1.StellarStationService/ListPlans":                              true,
+	"/stellarstation.api.v1.StellarStationService/ListUpcomingAvailablePasses":            true,
+	"/stellarstation.api.v1.groundstation.GroundStationService/ListPlans":                 true,
+	"/stellarstation.api.v1.groundstation.GroundStationService/ListUnavailabilityWindows": true,
+}
+
+// CallError is the error of a unary API call, describing whether it timed out, was not authorized or failed on the
+// server. The gRPC status of the call is kept, so status.Code and status.FromError work on it.
+type CallError struct {
+	// The name of the method, e.g. ListPlans.
+	Method string
+	// Number of attempts made.
+	Attempts int
+	// The deadline of the call set with --timeout, if it expired.
+	Timeout time.Duration
+
+	status *status.Status
+}
+
+func (e *CallError) Error() string {
+	message := e.status.Message()
+	var description string
+	switch e.status.Code() {
+	case codes.DeadlineExceeded:
+		if e.Timeout > 0 {
+			description = fmt.Sprintf("timed out after %v, raise --timeout if the API is slow to respond", e.Timeout)
+		} else {
+			description = fmt.Sprintf("timed out: %s", message)
+		}
+	case codes.Canceled:
+		description = "cancelled"
+	case codes.Unauthenticated:
+		description = fmt.Sprintf("authentication failed, check the API key with 'stellar auth activate-api-key': %s",
+			message)
+	case codes.PermissionDenied:
+		description = fmt.Sprintf("permission denied: %s", message)
+	case codes.NotFound:
+		description = fmt.Sprintf("not found: %s", message)
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange, codes.AlreadyExists:
+		description = fmt.Sprintf("rejected by the API: %s", message)
+	case codes.Unavailable:
+		description = fmt.Sprintf("API unavailable: %s", message)
+	case codes.ResourceExhausted:
+		description = fmt.Sprintf("rate limited or too large: %s", message)
+	default:
+		description = fmt.Sprintf("server error (%s): %s", e.status.Code(), message)
+	}
+	if e.Attempts > 1 {
+		description += fmt.Sprintf(" (after %d attempts)", e.Attempts)
+	}
+	return fmt.Sprintf("%s %s", e.Method, description)
+}
+
+// GRPCStatus returns the gRPC status of the call.
+func (e *CallError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// NotFoundError returns an error for something missing from an API response, e.g. a plan that is not in the list of
+// plans. Like a failed call, it carries the NotFound status.
+func NotFoundError(format string, a ...interface{}) error {
+	return &notFoundError{status: status.Newf(codes.NotFound, format, a...)}
+}
+
+type notFoundError struct {
+	status *status.Status
+}
+
+func (e *notFoundError) Error() string {
+	return e.status.Message()
+}
+
+func (e *notFoundError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// Return the unary interceptor applying the timeout and retry policy of the options to every call.
+func unaryInterceptor(o Options) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if o.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+			defer cancel()
+		}
+
+		exponential := backoff.NewExponentialBackOff()
+		exponential.InitialInterval = 500 * time.Millisecond
+		exponential.MaxElapsedTime = 0
+		// WithMaxRetries treats 0 as unlimited, so calls that must not be retried stop right away.
+		var policy backoff.BackOff = &backoff.StopBackOff{}
+		if idempotentMethods[method] && o.Retries > 0 {
+			policy = backoff.WithMaxRetries(exponential, uint64(o.Retries))
+		}
+
+		attempts := 0
+		err := backoff.RetryNotify(func() error {
+			attempts++
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err != nil && status.Code(err) != codes.Unavailable {
+				return backoff.Permanent(err)
+			}
+			return err
+		}, backoff.WithContext(policy, ctx),
+			func(err error, wait time.Duration) {
+				log.Printf("%s: %v. Retrying in %v", path.Base(method), err, wait)
+			})
+		if err == nil {
+			return nil
+		}
+
+		// The backoff returns the context error if the deadline expires while waiting for the next attempt.
+		s, ok := status.FromError(err)
+		if !ok {
+			s = status.FromContextError(err)
+		}
+		callErr := &CallError{Method: path.Base(method), Attempts: attempts, status: s}
+		if s.Code() == codes.DeadlineExceeded && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			callErr.Timeout = o.Timeout
+		}
+		return callErr
+	}
+}
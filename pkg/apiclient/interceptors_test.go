@@ -0,0 +1,126 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	listPlansMethod   = "/stellarstation.api.v1.StellarStationService/ListPlans"
+	reservePassMethod = "/stellarstation.api.v1.StellarStationService/ReservePass"
+)
+
+// Return an invoker failing with the given errors in turn, then succeeding, and a pointer to its number of calls.
+func fakeInvoker(errs ...error) (grpc.UnaryInvoker, *int) {
+	calls := 0
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		opts ...grpc.CallOption) error {
+		calls++
+		if calls <= len(errs) {
+			return errs[calls-1]
+		}
+		return nil
+	}, &calls
+}
+
+func TestUnaryInterceptorRetriesIdempotentCalls(t *testing.T) {
+	interceptor := unaryInterceptor(Options{Timeout: time.Minute, Retries: 3})
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	invoker, calls := fakeInvoker(unavailable, unavailable)
+	if err := interceptor(context.Background(), listPlansMethod, nil, nil, nil, invoker); err != nil {
+		t.Fatalf("expected the call to succeed after retries, got %v", err)
+	}
+	if *calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", *calls)
+	}
+
+	invoker, calls = fakeInvoker(unavailable)
+	err := interceptor(context.Background(), reservePassMethod, nil, nil, nil, invoker)
+	if *calls != 1 {
+		t.Errorf("expected ReservePass not to be retried, got %d attempts", *calls)
+	}
+	var callErr *CallError
+	if !errors.As(err, &callErr) {
+		t.Fatalf("expected a CallError, got %v", err)
+	}
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected the status code to be kept, got %v", status.Code(err))
+	}
+
+	invoker, calls = fakeInvoker(status.Error(codes.NotFound, "no such satellite"))
+	err = interceptor(context.Background(), listPlansMethod, nil, nil, nil, invoker)
+	if *calls != 1 {
+		t.Errorf("expected NotFound not to be retried, got %d attempts", *calls)
+	}
+	if err == nil || err.Error() != "ListPlans not found: no such satellite" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUnaryInterceptorGivesUpAfterRetries(t *testing.T) {
+	interceptor := unaryInterceptor(Options{Retries: 1})
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	invoker, calls := fakeInvoker(unavailable, unavailable, unavailable)
+	err := interceptor(context.Background(), listPlansMethod, nil, nil, nil, invoker)
+	if *calls != 2 {
+		t.Errorf("expected 2 attempts, got %d", *calls)
+	}
+	if err == nil || !strings.HasSuffix(err.Error(), "(after 2 attempts)") {
+		t.Errorf("expected the attempts in the error, got %v", err)
+	}
+}
+
+func TestUnaryInterceptorTimeout(t *testing.T) {
+	interceptor := unaryInterceptor(Options{Timeout: 50 * time.Millisecond})
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		opts ...grpc.CallOption) error {
+		<-ctx.Done()
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	err := interceptor(context.Background(), listPlansMethod, nil, nil, nil, invoker)
+	var callErr *CallError
+	if !errors.As(err, &callErr) {
+		t.Fatalf("expected a CallError, got %v", err)
+	}
+	if callErr.Timeout != 50*time.Millisecond {
+		t.Errorf("expected the timeout to be recorded, got %v", callErr.Timeout)
+	}
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %v", status.Code(err))
+	}
+	if !strings.Contains(err.Error(), "--timeout") {
+		t.Errorf("expected the error to mention --timeout, got %v", err)
+	}
+}
+
+func TestCallErrorUnauthenticated(t *testing.T) {
+	err := &CallError{Method: "GetTle", Attempts: 1, status: status.New(codes.Unauthenticated, "invalid token")}
+	expected := "GetTle authentication failed, check the API key with 'stellar auth activate-api-key': invalid token"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
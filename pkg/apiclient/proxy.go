@@ -0,0 +1,182 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+)
+
+// ParseProxyURL parses and validates a proxy URL. Supported schemes are http, https, socks5 and socks5h. User and
+// password in the URL are used to authenticate with the proxy.
+func ParseProxyURL(u string) (*url.URL, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q in %s, expected one of http, https, socks5, socks5h",
+			parsed.Scheme, parsed.Redacted())
+	}
+	if parsed.Hostname() == "" {
+		return nil, fmt.Errorf("proxy URL %s has no host", parsed.Redacted())
+	}
+	return parsed, nil
+}
+
+// contextDialer dials a network connection. It is the signature expected by grpc.WithContextDialer.
+type contextDialer func(ctx context.Context, addr string) (net.Conn, error)
+
+// Return the proxy to reach the given API endpoint through, or nil to connect directly.
+func selectProxy(explicit string, env *httpproxy.Config, apiUrl string) (*url.URL, error) {
+	if explicit != "" {
+		return ParseProxyURL(explicit)
+	}
+	u, err := env.ProxyFunc()(&url.URL{Scheme: "https", Host: apiUrl})
+	if err != nil || u == nil {
+		return nil, err
+	}
+	return ParseProxyURL(u.String())
+}
+
+// Create the dialer for the API connection, with a description of the route for the log.
+func newDialer(explicit string, env *httpproxy.Config, apiUrl string) (contextDialer, string, error) {
+	direct := &net.Dialer{}
+	proxyUrl, err := selectProxy(explicit, env, apiUrl)
+	if err != nil {
+		return nil, "", err
+	}
+	if proxyUrl == nil {
+		return func(ctx context.Context, addr string) (net.Conn, error) {
+			return direct.DialContext(ctx, "tcp", addr)
+		}, "direct", nil
+	}
+
+	switch proxyUrl.Scheme {
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if proxyUrl.User != nil {
+			password, _ := proxyUrl.User.Password()
+			auth = &proxy.Auth{User: proxyUrl.User.Username(), Password: password}
+		}
+		socks, err := proxy.SOCKS5("tcp", proxyHost(proxyUrl), auth, direct)
+		if err != nil {
+			return nil, "", err
+		}
+		return func(ctx context.Context, addr string) (net.Conn, error) {
+			return socks.(proxy.ContextDialer).DialContext(ctx, "tcp", addr)
+		}, "proxy " + proxyUrl.Redacted(), nil
+	default:
+		return func(ctx context.Context, addr string) (net.Conn, error) {
+			return dialConnect(ctx, direct, proxyUrl, addr)
+		}, "proxy " + proxyUrl.Redacted(), nil
+	}
+}
+
+// Return host:port of the proxy, with the default port of the scheme if none is given.
+func proxyHost(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}[u.Scheme]
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// Open a tunnel to addr through an HTTP proxy with the CONNECT method.
+func dialConnect(ctx context.Context, dialer *net.Dialer, proxyUrl *url.URL, addr string) (net.Conn, error) {
+	conn, err := dialer.DialContext(ctx, "tcp", proxyHost(proxyUrl))
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to proxy: %w", err)
+	}
+	if proxyUrl.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyUrl.Hostname(), MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("could not connect to proxy: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	// Give up on the proxy when the context ends during the CONNECT request.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if proxyUrl.User != nil {
+		password, _ := proxyUrl.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyUrl.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not send CONNECT request to proxy: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("could not read CONNECT response from proxy: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		if resp.StatusCode == http.StatusProxyAuthRequired {
+			return nil, fmt.Errorf("proxy %s requires authentication: %s", proxyUrl.Redacted(), resp.Status)
+		}
+		return nil, fmt.Errorf("proxy %s refused to connect to %s: %s", proxyUrl.Redacted(), addr, resp.Status)
+	}
+
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a connection whose first bytes were already read into a buffer.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
@@ -0,0 +1,253 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Start a server that echoes everything it receives and return its address.
+func startEchoServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// Serve connections with handle until the test ends and return the address.
+func startProxy(t *testing.T, handle func(conn net.Conn)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// Connect conn to addr and copy data both ways until either side closes.
+func relay(conn net.Conn, reader io.Reader, addr string) {
+	target, err := net.Dial("tcp", addr)
+	if err != nil {
+		return
+	}
+	defer target.Close()
+	go func() { _, _ = io.Copy(target, reader) }()
+	_, _ = io.Copy(conn, target)
+}
+
+// An HTTP CONNECT proxy stand-in requiring the given credentials. It records the requested targets.
+func startConnectProxy(t *testing.T, user, password string, targets chan<- string) string {
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+	return startProxy(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		req, err := http.ReadRequest(reader)
+		if err != nil || req.Method != http.MethodConnect {
+			return
+		}
+		targets <- req.Host
+		if req.Header.Get("Proxy-Authorization") != want {
+			_, _ = io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+			return
+		}
+		_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		relay(conn, reader, req.Host)
+	})
+}
+
+// A SOCKS5 proxy stand-in requiring username and password authentication. It records the requested targets.
+func startSocksProxy(t *testing.T, user, password string, targets chan<- string) string {
+	return startProxy(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		buf := make([]byte, 256)
+		// Greeting: version, methods.
+		if _, err := io.ReadFull(reader, buf[:2]); err != nil {
+			return
+		}
+		if _, err := io.ReadFull(reader, buf[:buf[1]]); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte{5, 2})
+		// Username and password authentication.
+		readString := func() string {
+			n, _ := reader.ReadByte()
+			_, _ = io.ReadFull(reader, buf[:n])
+			return string(buf[:n])
+		}
+		_, _ = reader.ReadByte()
+		u, p := readString(), readString()
+		if u != user || p != password {
+			_, _ = conn.Write([]byte{1, 1})
+			return
+		}
+		_, _ = conn.Write([]byte{1, 0})
+		// Request: version, command, reserved, address type, address, port.
+		if _, err := io.ReadFull(reader, buf[:4]); err != nil || buf[1] != 1 {
+			return
+		}
+		var host string
+		switch buf[3] {
+		case 1:
+			_, _ = io.ReadFull(reader, buf[:4])
+			host = net.IP(buf[:4]).String()
+		case 3:
+			host = readString()
+		default:
+			return
+		}
+		_, _ = io.ReadFull(reader, buf[:2])
+		addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2]))))
+		targets <- addr
+		_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+		relay(conn, reader, addr)
+	})
+}
+
+// Send a message through the connection and check that it is echoed.
+func checkEcho(t *testing.T, conn net.Conn) {
+	t.Helper()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.WriteString(conn, "ping"); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "ping" {
+		t.Errorf("echo = %q", buf)
+	}
+}
+
+func TestDialThroughProxy(t *testing.T) {
+	target := startEchoServer(t)
+	noEnv := &httpproxy.Config{}
+	ctx := context.Background()
+
+	targets := make(chan string, 10)
+	connectProxy := startConnectProxy(t, "ops", "s3cret", targets)
+	socksProxy := startSocksProxy(t, "ops", "s3cret", targets)
+
+	for _, proxyUrl := range []string{"http://ops:s3cret@" + connectProxy, "socks5://ops:s3cret@" + socksProxy} {
+		dialer, route, err := newDialer(proxyUrl, noEnv, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(route, "s3cret") {
+			t.Errorf("route %q should not contain the password", route)
+		}
+		conn, err := dialer(ctx, target)
+		if err != nil {
+			t.Fatalf("%s: %v", proxyUrl, err)
+		}
+		checkEcho(t, conn)
+		conn.Close()
+		if got := <-targets; got != target {
+			t.Errorf("%s: proxy was asked for %s, want %s", proxyUrl, got, target)
+		}
+	}
+
+	for _, proxyUrl := range []string{"http://ops:wrong@" + connectProxy, "socks5://ops:wrong@" + socksProxy} {
+		dialer, _, err := newDialer(proxyUrl, noEnv, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conn, err := dialer(ctx, target); err == nil {
+			conn.Close()
+			t.Errorf("%s: dial should fail with wrong credentials", proxyUrl)
+		}
+	}
+	dialer, _, _ := newDialer("http://ops:wrong@"+connectProxy, noEnv, target)
+	if _, err := dialer(ctx, target); err == nil || !strings.Contains(err.Error(), "requires authentication") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestSelectProxy(t *testing.T) {
+	env := &httpproxy.Config{HTTPSProxy: "http://proxy.example:3128", NoProxy: "internal.example,.corp.example"}
+
+	for apiUrl, want := range map[string]string{
+		"api.stellarstation.com:443":  "http://proxy.example:3128",
+		"internal.example:443":        "",
+		"api.corp.example:443":        "",
+		"localhost:9000":              "",
+		"api.qa.stellarstation.com:1": "http://proxy.example:3128",
+	} {
+		u, err := selectProxy("", env, apiUrl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if u != nil {
+			got = u.String()
+		}
+		if got != want {
+			t.Errorf("%s: proxy = %q, want %q", apiUrl, got, want)
+		}
+	}
+
+	// An explicit proxy is used even for hosts excluded by NO_PROXY.
+	u, err := selectProxy("socks5h://bastion:1080", env, "internal.example:443")
+	if err != nil || u.String() != "socks5h://bastion:1080" {
+		t.Errorf("explicit proxy = %v, %v", u, err)
+	}
+
+	for _, invalid := range []string{"ftp://proxy:21", "http://", "://bad"} {
+		if _, err := ParseProxyURL(invalid); err == nil {
+			t.Errorf("%q should be rejected", invalid)
+		}
+	}
+	if got := proxyHost(&url.URL{Scheme: "socks5", Host: "bastion"}); got != "bastion:1080" {
+		t.Errorf("default port = %s", got)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+// Build the TLS configuration for the API connection, with a description of how the server is verified and how the
+// client authenticates.
+func newTLSConfig(settings config.TLSSettings) (*tls.Config, string, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: settings.ServerName,
+	}
+	var mode []string
+
+	switch {
+	case settings.IsInsecure():
+		tlsConfig.InsecureSkipVerify = true
+		mode = append(mode, "INSECURE, the server certificate is not verified")
+	case settings.CAFile != "":
+		pem, err := os.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("could not read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, "", fmt.Errorf("no certificates found in CA file %s", settings.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+		mode = append(mode, fmt.Sprintf("server certificate verified with the system CAs and %s", settings.CAFile))
+	default:
+		mode = append(mode, "server certificate verified with the system CAs")
+	}
+
+	if settings.ServerName != "" {
+		mode = append(mode, fmt.Sprintf("server name %s", settings.ServerName))
+	}
+
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		if settings.CertFile == "" || settings.KeyFile == "" {
+			return nil, "", fmt.Errorf("a client certificate requires both a certificate and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("could not load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		mode = append(mode, fmt.Sprintf("client certificate %s", settings.CertFile))
+	}
+
+	return tlsConfig, strings.Join(mode, ", "), nil
+}
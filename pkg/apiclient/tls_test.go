@@ -0,0 +1,231 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, name string, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+// Write the certificate and its key as PEM files and return their paths.
+func (c *testCert) write(t *testing.T, dir, name string) (string, string) {
+	certFile := filepath.Join(dir, name+".pem")
+	keyFile := filepath.Join(dir, name+"-key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// Perform a TLS handshake with a server presenting a certificate for api.test and requiring a client certificate
+// signed by the CA if requireClientCert is set.
+func handshake(t *testing.T, clientConfig *tls.Config, ca, server *testCert, requireClientCert bool) error {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	serverConfig := &tls.Config{
+		Certificates: []tls.Certificate{server.tlsCertificate()},
+		ClientCAs:    pool,
+	}
+	if requireClientCert {
+		serverConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	serverDone := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			serverDone <- err
+			return
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+		serverDone <- tls.Server(conn, serverConfig).Handshake()
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	client := tls.Client(conn, clientConfig)
+	clientErr := client.Handshake()
+	if clientErr == nil {
+		// With TLS 1.3 the server verifies the client certificate after the client completed the handshake.
+		_, clientErr = client.Read(make([]byte, 1))
+		if clientErr == io.EOF {
+			clientErr = nil
+		}
+	}
+	conn.Close()
+	serverErr := <-serverDone
+	if clientErr != nil {
+		return clientErr
+	}
+	return serverErr
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "Test CA", nil, x509.ExtKeyUsageAny)
+	server := newTestCert(t, "api.test", ca, x509.ExtKeyUsageServerAuth)
+	client := newTestCert(t, "client.test", ca, x509.ExtKeyUsageClientAuth)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := client.write(t, dir, "client")
+
+	// The system CAs do not know the test CA.
+	tlsConfig, mode, err := newTLSConfig(config.TLSSettings{ServerName: "api.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode != "server certificate verified with the system CAs, server name api.test" {
+		t.Errorf("mode = %q", mode)
+	}
+	if err := handshake(t, tlsConfig, ca, server, false); err == nil {
+		t.Errorf("handshake should fail without the CA file")
+	}
+
+	tlsConfig, mode, err = newTLSConfig(config.TLSSettings{CAFile: caFile, ServerName: "api.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(mode, caFile) {
+		t.Errorf("mode = %q", mode)
+	}
+	if err := handshake(t, tlsConfig, ca, server, false); err != nil {
+		t.Errorf("handshake with the CA file: %v", err)
+	}
+	if err := handshake(t, tlsConfig, ca, server, true); err == nil {
+		t.Errorf("handshake should fail without a client certificate")
+	}
+
+	tlsConfig, _, err = newTLSConfig(config.TLSSettings{CAFile: caFile, ServerName: "other.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := handshake(t, tlsConfig, ca, server, false); err == nil {
+		t.Errorf("handshake should fail for a different server name")
+	}
+
+	tlsConfig, mode, err = newTLSConfig(config.TLSSettings{
+		CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "api.test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(mode, "client certificate "+certFile) {
+		t.Errorf("mode = %q", mode)
+	}
+	if err := handshake(t, tlsConfig, ca, server, true); err != nil {
+		t.Errorf("handshake with a client certificate: %v", err)
+	}
+
+	insecure := true
+	tlsConfig, mode, err = newTLSConfig(config.TLSSettings{Insecure: &insecure, ServerName: "api.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(mode, "INSECURE") {
+		t.Errorf("mode = %q", mode)
+	}
+	if err := handshake(t, tlsConfig, ca, server, false); err != nil {
+		t.Errorf("insecure handshake: %v", err)
+	}
+
+	if _, _, err := newTLSConfig(config.TLSSettings{CertFile: certFile}); err == nil {
+		t.Errorf("a certificate without a key should be rejected")
+	}
+	if _, _, err := newTLSConfig(config.TLSSettings{CAFile: keyFile}); err == nil {
+		t.Errorf("a CA file without certificates should be rejected")
+	}
+}
+
+func TestMergeTLSSettings(t *testing.T) {
+	profile := config.TLSSettings{CAFile: "profile-ca.pem", CertFile: "profile.pem", KeyFile: "profile-key.pem"}
+	flags := config.TLSSettings{CertFile: "flag.pem", KeyFile: "flag-key.pem", ServerName: "api.test"}
+	merged := flags.Merge(profile)
+	want := config.TLSSettings{CAFile: "profile-ca.pem", CertFile: "flag.pem", KeyFile: "flag-key.pem", ServerName: "api.test"}
+	if merged != want {
+		t.Errorf("merged = %+v, want %+v", merged, want)
+	}
+}
@@ -15,9 +15,9 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/oauth"
@@ -25,38 +25,146 @@ import (
 	"github.com/infostellarinc/stellarcli/pkg/config"
 )
 
+// CredentialsError is returned when no usable API key is found.
+type CredentialsError struct {
+	Err error
+}
+
+func (e *CredentialsError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CredentialsError) Unwrap() error {
+	return e.Err
+}
+
+// CredentialsEnv names an API key file used instead of the key activated for the profile.
+const CredentialsEnv = "STELLAR_CREDENTIALS"
+
+// SourceEnv is the source of a key given with STELLAR_CREDENTIALS. Keys of a profile have the name of their
+// credential store as source.
+const SourceEnv = "environment"
+
+// KeyLocation is where the API key in use was found.
+type KeyLocation struct {
+	// Where the key is kept, e.g. the path of a file.
+	Location string
+	// SourceEnv, or the name of the credential store keeping the key of the profile.
+	Source string
+	// The profile in use.
+	Profile string
+
+	store CredentialStore
+}
+
+// Read returns the API key.
+func (l *KeyLocation) Read() ([]byte, error) {
+	var key []byte
+	var err error
+	if l.store == nil {
+		key, err = os.ReadFile(l.Location)
+	} else {
+		key, err = l.store.Get(l.Profile)
+	}
+	if err != nil {
+		return nil, asCredentialsError(fmt.Errorf("could not read API key: %w", err))
+	}
+	return key, nil
+}
+
 // NewDefaultCredentials initializes gRPC credentials using Stellar Default Credentials.
 func NewDefaultCredentials() (credentials.PerRPCCredentials, error) {
-	return oauth.NewJWTAccessFromFile(findDefaultCredentials())
+	location, err := FindDefaultCredentials()
+	if err != nil {
+		return nil, err
+	}
+	key, err := location.Read()
+	if err != nil {
+		return nil, err
+	}
+	// Check the key first, so a broken key is reported as such instead of failing every request.
+	if _, err := ParseKey(location.Location, key); err != nil {
+		return nil, err
+	}
+	creds, err := oauth.NewJWTAccessFromKey(key)
+	if err != nil {
+		return nil, &CredentialsError{Err: fmt.Errorf("invalid API key: %w", err)}
+	}
+	return creds, nil
 }
 
-// StoreCredentialsFile stores the API key at the given path to a well known location.
-func StoreCredentialsFile(path string) error {
+// StoreCredentials verifies the API key at the given path and stores it in the credential store for the profile.
+// Copies of the key in the files of the other stores and in the previous credential helper of the profile are
+// removed, and the credential helper of the profile is updated; the caller saves the profile.
+func StoreCredentials(path string, profile *config.Profile, store CredentialStore) (*KeyInfo, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("could not read credentials file: %w", err)
+		return nil, fmt.Errorf("could not read credentials file: %w", err)
+	}
+	info, err := ParseKey(path, content)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.Store(profile.Name, content); err != nil {
+		return nil, err
+	}
+	for _, other := range []CredentialStore{newEncryptedStore(), fileStore{}} {
+		if other.Name() == store.Name() {
+			continue
+		}
+		if err := other.Erase(profile.Name); err != nil {
+			return nil, fmt.Errorf("could not remove the API key from %s: %w", other.Location(profile.Name), err)
+		}
 	}
 
-	_ = config.EnsureConfigDir()
+	helper := ""
+	if h, ok := store.(*helperStore); ok {
+		helper = h.helper
+	}
+	if old := profile.CredentialHelper; old != "" && old != helper {
+		if err := (&helperStore{helper: old}).Erase(profile.Name); err != nil {
+			return nil, fmt.Errorf("could not remove the API key from credential helper %s: %w", old, err)
+		}
+	}
+	profile.CredentialHelper = helper
+	return info, nil
+}
 
-	if err := os.WriteFile(wellKnownFile(), content, 0600); err != nil {
-		return fmt.Errorf("could not write to config directory: %w", err)
+// HasCredentials returns whether an API key has been activated for the given profile.
+func HasCredentials(name string) bool {
+	profile, err := loadProfile(name)
+	if err != nil {
+		return false
 	}
-	return nil
+	_, ok := findStore(profile)
+	return ok
 }
 
-func findDefaultCredentials() string {
+// FindDefaultCredentials returns where the API key in use is: the file in STELLAR_CREDENTIALS, then the credential
+// helper of the profile in use, then its encrypted key file, then its plaintext key file.
+func FindDefaultCredentials() (*KeyLocation, error) {
 	// First, try the environment variable.
-	const envVar = "STELLAR_CREDENTIALS"
-	if filename := os.Getenv(envVar); filename != "" {
-		return filename
+	if filename := os.Getenv(CredentialsEnv); filename != "" {
+		return &KeyLocation{Location: filename, Source: SourceEnv}, nil
 	}
 
-	// Second, try a well-known file.
-	return wellKnownFile()
-}
-
-func wellKnownFile() string {
-	const f = "stellarstation_credentials.json"
-	return filepath.Join(config.GetConfigDir(), f)
+	// Second, try the credential stores of the profile in use.
+	profile, err := config.CurrentProfile()
+	if err != nil {
+		return nil, err
+	}
+	store, ok := findStore(profile)
+	if !ok {
+		if profile.Name == config.DefaultProfile {
+			return nil, &CredentialsError{
+				Err: errors.New("no API key activated, run 'stellar auth activate-api-key path/to/key.json'"),
+			}
+		}
+		return nil, &CredentialsError{Err: fmt.Errorf(
+			"no API key activated for profile %q, run 'stellar auth activate-api-key --profile %s path/to/key.json'",
+			profile.Name, profile.Name)}
+	}
+	return &KeyLocation{Location: store.Location(profile.Name), Source: store.Name(), Profile: profile.Name,
+		store: store}, nil
 }
@@ -0,0 +1,196 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/term"
+
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+// PassphraseEnv holds the passphrase of encrypted API keys, for use without a terminal.
+const PassphraseEnv = "STELLAR_KEY_PASSPHRASE"
+
+const (
+	encryptedKeyVersion = 1
+	encryptedKeyKDF     = "pbkdf2-sha256"
+	saltSize            = 16
+)
+
+// Iterations of PBKDF2 used when encrypting a key. Tests lower it.
+var pbkdf2Iterations = 600000
+
+// The content of an encrypted key file. The key is encrypted with AES-256-GCM, using a key derived from the
+// passphrase with PBKDF2.
+type encryptedKey struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// encryptedStore keeps the API key in the profile directory, encrypted with a passphrase.
+type encryptedStore struct {
+	// Returns the passphrase for the key of the profile. confirm is set when a new key is stored.
+	passphrase func(profile string, confirm bool) ([]byte, error)
+}
+
+func newEncryptedStore() *encryptedStore {
+	return &encryptedStore{passphrase: readPassphrase}
+}
+
+func (s *encryptedStore) Name() string {
+	return StoreEncrypted
+}
+
+func (s *encryptedStore) Location(profile string) string {
+	return encryptedKeyFile(profile)
+}
+
+func (s *encryptedStore) Has(profile string) bool {
+	_, err := os.Stat(encryptedKeyFile(profile))
+	return err == nil
+}
+
+func (s *encryptedStore) Get(profile string) ([]byte, error) {
+	content, err := os.ReadFile(encryptedKeyFile(profile))
+	if err != nil {
+		return nil, err
+	}
+	var encrypted encryptedKey
+	if err := json.Unmarshal(content, &encrypted); err != nil {
+		return nil, fmt.Errorf("could not parse encrypted API key: %w", err)
+	}
+	if encrypted.Version != encryptedKeyVersion || encrypted.KDF != encryptedKeyKDF {
+		return nil, fmt.Errorf("unsupported encrypted API key version %d with %q", encrypted.Version, encrypted.KDF)
+	}
+
+	passphrase, err := s.passphrase(profile, false)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newKeyCipher(passphrase, encrypted.Salt, encrypted.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	key, err := aead.Open(nil, encrypted.Nonce, encrypted.Ciphertext, nil)
+	if err != nil {
+		return nil, &CredentialsError{Err: errors.New("could not decrypt API key: wrong passphrase or corrupted file")}
+	}
+	return key, nil
+}
+
+func (s *encryptedStore) Store(profile string, key []byte) error {
+	passphrase, err := s.passphrase(profile, true)
+	if err != nil {
+		return err
+	}
+
+	encrypted := encryptedKey{
+		Version:    encryptedKeyVersion,
+		KDF:        encryptedKeyKDF,
+		Iterations: pbkdf2Iterations,
+		Salt:       make([]byte, saltSize),
+	}
+	if _, err := rand.Read(encrypted.Salt); err != nil {
+		return err
+	}
+	aead, err := newKeyCipher(passphrase, encrypted.Salt, encrypted.Iterations)
+	if err != nil {
+		return err
+	}
+	encrypted.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(encrypted.Nonce); err != nil {
+		return err
+	}
+	encrypted.Ciphertext = aead.Seal(nil, encrypted.Nonce, key, nil)
+
+	content, err := json.Marshal(encrypted)
+	if err != nil {
+		return err
+	}
+	return writeProfileFile(encryptedKeyFile(profile), content)
+}
+
+func (s *encryptedStore) Erase(profile string) error {
+	return removeFile(encryptedKeyFile(profile))
+}
+
+func encryptedKeyFile(profile string) string {
+	const f = "stellarstation_credentials.json.enc"
+	return filepath.Join(config.GetProfileDir(profile), f)
+}
+
+// Return the AES-256-GCM cipher with the key derived from the passphrase.
+func newKeyCipher(passphrase, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations <= 0 || len(salt) == 0 {
+		return nil, errors.New("invalid key derivation parameters")
+	}
+	block, err := aes.NewCipher(pbkdf2.Key(passphrase, salt, iterations, 32, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Return the passphrase from the environment, or ask for it on the terminal.
+func readPassphrase(profile string, confirm bool) ([]byte, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, &CredentialsError{Err: fmt.Errorf(
+			"the API key of profile %s is encrypted, set %s or run stellar in a terminal", profile, PassphraseEnv)}
+	}
+	fmt.Fprintf(os.Stderr, "Passphrase for the API key of profile %s: ", profile)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("could not read passphrase: %w", err)
+	}
+	if len(passphrase) == 0 {
+		return nil, &CredentialsError{Err: errors.New("empty passphrase")}
+	}
+	if !confirm {
+		return passphrase, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Repeat the passphrase: ")
+	repeated, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("could not read passphrase: %w", err)
+	}
+	if !hmac.Equal(passphrase, repeated) {
+		return nil, &CredentialsError{Err: errors.New("the passphrases do not match")}
+	}
+	return passphrase, nil
+}
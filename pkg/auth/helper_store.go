@@ -0,0 +1,125 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Prefix of the commands of credential helpers given by name.
+const helperCommandPrefix = "stellar-credential-"
+
+// helperStore keeps the API key with an external credential helper, in the style of git credential helpers.
+//
+// The helper is run with the action get, store or erase as its last argument, and receives key=value lines ending
+// with an empty line on its standard input: profile=<name> for every action, and key=<API key in base64> for store.
+// For get, it prints key=<API key in base64> on its standard output, or nothing if it has no key for the profile.
+//
+// The helper is either a name, for the command stellar-credential-<name> on the PATH, a command line with its
+// arguments, or a shell command line starting with '!'.
+type helperStore struct {
+	helper string
+}
+
+func (s *helperStore) Name() string {
+	return StoreHelper
+}
+
+func (s *helperStore) Location(profile string) string {
+	return strings.Join(s.command("get").Args, " ")
+}
+
+// Has returns true: whether the helper holds a key is only known by asking it.
+func (s *helperStore) Has(profile string) bool {
+	return true
+}
+
+func (s *helperStore) Get(profile string) ([]byte, error) {
+	output, err := s.run("get", profile, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		name, value, _ := strings.Cut(scanner.Text(), "=")
+		if name != "key" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("credential helper returned an invalid key: %w", err)
+		}
+		return key, nil
+	}
+	return nil, &CredentialsError{Err: fmt.Errorf("credential helper %s has no API key for profile %s", s.helper,
+		profile)}
+}
+
+func (s *helperStore) Store(profile string, key []byte) error {
+	_, err := s.run("store", profile, key)
+	return err
+}
+
+func (s *helperStore) Erase(profile string) error {
+	_, err := s.run("erase", profile, nil)
+	return err
+}
+
+// Run the helper with the action, returning its standard output.
+func (s *helperStore) run(action, profile string, key []byte) ([]byte, error) {
+	var input bytes.Buffer
+	fmt.Fprintf(&input, "profile=%s\n", profile)
+	if key != nil {
+		fmt.Fprintf(&input, "key=%s\n", base64.StdEncoding.EncodeToString(key))
+	}
+	input.WriteString("\n")
+
+	cmd := s.command(action)
+	cmd.Stdin = &input
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && message != "" {
+			err = errors.New(message)
+		}
+		return nil, fmt.Errorf("credential helper %s %s failed: %w", s.helper, action, err)
+	}
+	return output, nil
+}
+
+// Return the command running the helper with the action.
+func (s *helperStore) command(action string) *exec.Cmd {
+	if shell, ok := strings.CutPrefix(s.helper, "!"); ok {
+		// Like git, pass the action as an argument of the shell command line.
+		return exec.Command("sh", "-c", shell+` "$@"`, shell, action)
+	}
+
+	args := strings.Fields(s.helper)
+	if !strings.ContainsAny(args[0], `/\`) {
+		args[0] = helperCommandPrefix + args[0]
+	}
+	return exec.Command(args[0], append(args[1:], action)...)
+}
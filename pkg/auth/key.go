@@ -0,0 +1,76 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/oauth2/google"
+)
+
+// The audience used to check that a key can sign a JWT. Any URI works; the token is not sent.
+const verifyAudience = "https://api.stellarstation.com"
+
+// KeyInfo describes an API key. Keys use the service account format, in which the organisation is the project.
+type KeyInfo struct {
+	Type         string `json:"type"`
+	KeyID        string `json:"private_key_id"`
+	ClientEmail  string `json:"client_email"`
+	Organization string `json:"project_id"`
+}
+
+// ReadKey reads the API key in the file and verifies that it can sign a JWT, returning what it describes.
+func ReadKey(path string) (*KeyInfo, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read API key: %w", err)
+	}
+	return ParseKey(path, content)
+}
+
+// ParseKey parses the API key read from path and verifies that it can sign a JWT, returning what it describes.
+func ParseKey(path string, content []byte) (*KeyInfo, error) {
+	info := &KeyInfo{}
+	if err := json.Unmarshal(content, info); err != nil {
+		return nil, &CredentialsError{Err: fmt.Errorf("%s is not a JSON API key: %w", path, err)}
+	}
+	if info.ClientEmail == "" || info.KeyID == "" {
+		return nil, &CredentialsError{
+			Err: fmt.Errorf("%s is not an API key: private_key_id or client_email is missing", path),
+		}
+	}
+
+	// Sign a token the way the gRPC JWT credentials do for every request.
+	tokenSource, err := google.JWTAccessTokenSourceFromJSON(content, verifyAudience)
+	if err == nil {
+		_, err = tokenSource.Token()
+	}
+	if err != nil {
+		return nil, &CredentialsError{Err: fmt.Errorf("API key %s cannot sign requests: %w", path, err)}
+	}
+	return info, nil
+}
+
+// Return the error if it is a CredentialsError, or wrap it in one.
+func asCredentialsError(err error) error {
+	var credentialsErr *CredentialsError
+	if errors.As(err, &credentialsErr) {
+		return err
+	}
+	return &CredentialsError{Err: err}
+}
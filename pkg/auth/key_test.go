@@ -0,0 +1,139 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+func useTempHome(t *testing.T) {
+	homedir.DisableCache = true
+	t.Cleanup(func() { homedir.DisableCache = false })
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("APPDATA", t.TempDir())
+	t.Setenv(config.ProfileEnv, "")
+	t.Setenv(CredentialsEnv, "")
+}
+
+// Write an API key with the given private key to a temporary file and return its path.
+func writeKey(t *testing.T, privateKey string) string {
+	content, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "acme",
+		"client_email":   "ops@acme.example",
+		"private_key_id": "key1",
+		"private_key":    privateKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func generatePrivateKey(t *testing.T) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+func TestReadKey(t *testing.T) {
+	info, err := ReadKey(writeKey(t, generatePrivateKey(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.KeyID != "key1" || info.ClientEmail != "ops@acme.example" || info.Organization != "acme" {
+		t.Errorf("unexpected key info: %+v", info)
+	}
+
+	invalid := map[string]string{
+		"not JSON":       "not json",
+		"missing fields": `{"type": "service_account"}`,
+	}
+	for name, content := range invalid {
+		path := filepath.Join(t.TempDir(), "key.json")
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		var credentialsErr *CredentialsError
+		if _, err := ReadKey(path); !errors.As(err, &credentialsErr) {
+			t.Errorf("%s: expected a CredentialsError, got %v", name, err)
+		}
+	}
+
+	var credentialsErr *CredentialsError
+	if _, err := ReadKey(writeKey(t, "garbage")); !errors.As(err, &credentialsErr) {
+		t.Errorf("a key that cannot sign should be rejected, got %v", err)
+	}
+}
+
+func TestStoreAndFindCredentials(t *testing.T) {
+	useTempHome(t)
+
+	var credentialsErr *CredentialsError
+	if _, err := FindDefaultCredentials(); !errors.As(err, &credentialsErr) {
+		t.Errorf("expected a CredentialsError without an activated key, got %v", err)
+	}
+
+	profile := &config.Profile{Name: config.DefaultProfile}
+	if _, err := StoreCredentials(writeKey(t, "garbage"), profile, fileStore{}); err == nil {
+		t.Errorf("an invalid key should not be stored")
+	}
+	if HasCredentials(config.DefaultProfile) {
+		t.Errorf("an invalid key was stored")
+	}
+
+	info, err := StoreCredentials(writeKey(t, generatePrivateKey(t)), profile, fileStore{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.KeyID != "key1" {
+		t.Errorf("key ID = %q", info.KeyID)
+	}
+	location, err := FindDefaultCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.Source != StoreFile || location.Location != wellKnownFile(config.DefaultProfile) {
+		t.Errorf("unexpected location: %+v", location)
+	}
+
+	path := writeKey(t, generatePrivateKey(t))
+	t.Setenv(CredentialsEnv, path)
+	location, err = FindDefaultCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.Source != SourceEnv || location.Location != path {
+		t.Errorf("unexpected location: %+v", location)
+	}
+}
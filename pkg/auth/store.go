@@ -0,0 +1,157 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+// Names of the credential stores.
+const (
+	// The API key is kept as a plaintext file in the profile directory.
+	StoreFile = "file"
+	// The API key is kept in the profile directory, encrypted with a passphrase.
+	StoreEncrypted = "encrypted"
+	// The API key is kept by an external credential helper command.
+	StoreHelper = "helper"
+)
+
+// CredentialStores lists the names of the credential stores.
+var CredentialStores = []string{StoreFile, StoreEncrypted, StoreHelper}
+
+// CredentialStore keeps the API key of each profile.
+type CredentialStore interface {
+	// Name returns the name of the store, one of CredentialStores.
+	Name() string
+	// Location returns where the key of the profile is kept, e.g. the path of a file.
+	Location(profile string) string
+	// Has returns whether the store may hold a key for the profile, without reading it.
+	Has(profile string) bool
+	// Get returns the key of the profile.
+	Get(profile string) ([]byte, error)
+	// Store stores the key of the profile, replacing any key stored before.
+	Store(profile string, key []byte) error
+	// Erase removes the key of the profile. Erasing a key that is not stored is not an error.
+	Erase(profile string) error
+}
+
+// NewCredentialStore returns the credential store with the given name. The helper command is only used by the
+// helper store.
+func NewCredentialStore(name, helper string) (CredentialStore, error) {
+	switch name {
+	case StoreFile:
+		return fileStore{}, nil
+	case StoreEncrypted:
+		return newEncryptedStore(), nil
+	case StoreHelper:
+		if strings.TrimSpace(helper) == "" {
+			return nil, errors.New("the helper credential store requires a credential helper command")
+		}
+		return &helperStore{helper: helper}, nil
+	}
+	return nil, fmt.Errorf("unknown credential store %q, expected one of %v", name, CredentialStores)
+}
+
+// Return the stores the key of the profile is looked up in, in order: the credential helper configured for the
+// profile, then the encrypted file, then the plaintext file.
+func profileStores(profile *config.Profile) []CredentialStore {
+	var stores []CredentialStore
+	if strings.TrimSpace(profile.CredentialHelper) != "" {
+		stores = append(stores, &helperStore{helper: profile.CredentialHelper})
+	}
+	return append(stores, newEncryptedStore(), fileStore{})
+}
+
+// Return the first store holding a key for the profile.
+func findStore(profile *config.Profile) (CredentialStore, bool) {
+	for _, store := range profileStores(profile) {
+		if store.Has(profile.Name) {
+			return store, true
+		}
+	}
+	return nil, false
+}
+
+// Return the settings of the named profile. A profile without settings has none.
+func loadProfile(name string) (*config.Profile, error) {
+	profiles, err := config.LoadProfiles()
+	if err != nil {
+		return nil, err
+	}
+	if profile := profiles.Get(name); profile != nil {
+		return profile, nil
+	}
+	return &config.Profile{Name: name}, nil
+}
+
+// fileStore keeps the API key as a plaintext file with 0600 permissions in the profile directory.
+type fileStore struct{}
+
+func (fileStore) Name() string {
+	return StoreFile
+}
+
+func (fileStore) Location(profile string) string {
+	return wellKnownFile(profile)
+}
+
+func (fileStore) Has(profile string) bool {
+	_, err := os.Stat(wellKnownFile(profile))
+	return err == nil
+}
+
+func (fileStore) Get(profile string) ([]byte, error) {
+	return os.ReadFile(wellKnownFile(profile))
+}
+
+func (fileStore) Store(profile string, key []byte) error {
+	return writeProfileFile(wellKnownFile(profile), key)
+}
+
+func (fileStore) Erase(profile string) error {
+	return removeFile(wellKnownFile(profile))
+}
+
+func wellKnownFile(profile string) string {
+	const f = "stellarstation_credentials.json"
+	return filepath.Join(config.GetProfileDir(profile), f)
+}
+
+// Write a file readable only by the user to the directory of a profile, creating the directory if needed.
+func writeProfileFile(path string, content []byte) error {
+	_ = config.EnsureConfigDir()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("could not create profile directory: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return fmt.Errorf("could not write to config directory: %w", err)
+	}
+	return nil
+}
+
+// Remove the file. A missing file is not an error.
+func removeFile(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
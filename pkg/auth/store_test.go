@@ -0,0 +1,188 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+func TestEncryptedStore(t *testing.T) {
+	useTempHome(t)
+	defer func(iterations int) { pbkdf2Iterations = iterations }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	passphrase := "correct horse"
+	store := &encryptedStore{passphrase: func(profile string, confirm bool) ([]byte, error) {
+		return []byte(passphrase), nil
+	}}
+	if store.Has(config.DefaultProfile) {
+		t.Errorf("empty store should not have a key")
+	}
+
+	path := writeKey(t, generatePrivateKey(t))
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (fileStore{}).Store(config.DefaultProfile, content); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := StoreCredentials(path, &config.Profile{Name: config.DefaultProfile}, store); err != nil {
+		t.Fatal(err)
+	}
+	if (fileStore{}).Has(config.DefaultProfile) {
+		t.Errorf("the plaintext key should be removed when the key is encrypted")
+	}
+	encrypted, err := os.ReadFile(encryptedKeyFile(config.DefaultProfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(encrypted, []byte("PRIVATE KEY")) {
+		t.Errorf("the encrypted file contains the private key")
+	}
+
+	key, err := store.Get(config.DefaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, content) {
+		t.Errorf("decrypted key differs from the stored key")
+	}
+
+	passphrase = "wrong"
+	var credentialsErr *CredentialsError
+	if _, err := store.Get(config.DefaultProfile); !errors.As(err, &credentialsErr) {
+		t.Errorf("expected a CredentialsError for a wrong passphrase, got %v", err)
+	}
+
+	// The lookup finds the encrypted key and asks for the passphrase from the environment.
+	t.Setenv(PassphraseEnv, "correct horse")
+	location, err := FindDefaultCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.Source != StoreEncrypted {
+		t.Errorf("source = %q, want %q", location.Source, StoreEncrypted)
+	}
+	if _, err := NewDefaultCredentials(); err != nil {
+		t.Errorf("could not use the encrypted key: %v", err)
+	}
+}
+
+func TestHelperStore(t *testing.T) {
+	useTempHome(t)
+
+	// A helper keeping the key line of each profile in a file next to it.
+	dir := t.TempDir()
+	helper := filepath.Join(dir, "helper.sh")
+	script := `#!/bin/sh
+profile=
+key=
+while read -r line && [ -n "$line" ]; do
+	case "$line" in
+		profile=*) profile="${line#profile=}" ;;
+		key=*) key="$line" ;;
+	esac
+done
+case "$1" in
+	get) [ -f "` + dir + `/$profile" ] && cat "` + dir + `/$profile" ;;
+	store) echo "$key" > "` + dir + `/$profile" ;;
+	erase) rm -f "` + dir + `/$profile" ;;
+esac
+exit 0
+`
+	if err := os.WriteFile(helper, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewCredentialStore(StoreHelper, helper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := config.LoadProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := profiles.GetOrCreate("ops")
+	path := writeKey(t, generatePrivateKey(t))
+	if err := (fileStore{}).Store("ops", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := StoreCredentials(path, profile, store); err != nil {
+		t.Fatal(err)
+	}
+	if profile.CredentialHelper != helper {
+		t.Errorf("credential helper = %q, want %q", profile.CredentialHelper, helper)
+	}
+	if (fileStore{}).Has("ops") {
+		t.Errorf("the plaintext key should be removed when the key is kept by a helper")
+	}
+	if err := profiles.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	config.SelectProfile("ops")
+	t.Cleanup(func() { config.SelectProfile("") })
+	location, err := FindDefaultCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.Source != StoreHelper {
+		t.Errorf("source = %q, want %q", location.Source, StoreHelper)
+	}
+	key, err := location.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := os.ReadFile(path)
+	if !bytes.Equal(key, expected) {
+		t.Errorf("key from the helper differs from the stored key")
+	}
+
+	if err := store.Erase("ops"); err != nil {
+		t.Fatal(err)
+	}
+	var credentialsErr *CredentialsError
+	if _, err := location.Read(); !errors.As(err, &credentialsErr) {
+		t.Errorf("expected a CredentialsError after erasing the key, got %v", err)
+	}
+
+	// Switching to another store removes the key from the helper.
+	if err := store.Store("ops", expected); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := StoreCredentials(path, profile, fileStore{}); err != nil {
+		t.Fatal(err)
+	}
+	if profile.CredentialHelper != "" {
+		t.Errorf("credential helper = %q, want none", profile.CredentialHelper)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ops")); !os.IsNotExist(err) {
+		t.Errorf("the key should be removed from the helper when it is kept in another store")
+	}
+
+	if _, err := NewCredentialStore(StoreHelper, " "); err == nil {
+		t.Errorf("the helper store requires a helper")
+	}
+	if _, err := NewCredentialStore("keyring", ""); err == nil {
+		t.Errorf("unknown stores should be rejected")
+	}
+}
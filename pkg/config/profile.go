@@ -0,0 +1,230 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile is the profile used when none is selected. Its API key is kept at the location used before profiles
+// existed, so existing installations keep working.
+const DefaultProfile = "default"
+
+// ProfileEnv selects the profile when the --profile flag is not given.
+const ProfileEnv = "STELLAR_PROFILE"
+
+const profilesFile = "profiles.yaml"
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// The profile selected with the --profile flag, if any.
+var selectedProfile string
+
+// TLSSettings holds the TLS settings used to connect to the API endpoint of a profile.
+type TLSSettings struct {
+	// Skip verification of the server certificate. Nil when not set, so that false can override another setting.
+	Insecure *bool `yaml:"insecure,omitempty"`
+	// PEM file with the certificate authorities trusted in addition to the system pool.
+	CAFile string `yaml:"ca-file,omitempty"`
+	// PEM files with the client certificate and its private key, for mutual TLS.
+	CertFile string `yaml:"cert-file,omitempty"`
+	KeyFile  string `yaml:"key-file,omitempty"`
+	// The server name to send with SNI and verify the certificate against, instead of the host of the endpoint.
+	ServerName string `yaml:"server-name,omitempty"`
+}
+
+// IsInsecure returns whether verification of the server certificate is skipped.
+func (s TLSSettings) IsInsecure() bool {
+	return s.Insecure != nil && *s.Insecure
+}
+
+// Merge returns the settings with every setting not given in s taken from other.
+func (s TLSSettings) Merge(other TLSSettings) TLSSettings {
+	if s.Insecure == nil {
+		s.Insecure = other.Insecure
+	}
+	if s.CAFile == "" {
+		s.CAFile = other.CAFile
+	}
+	if s.CertFile == "" && s.KeyFile == "" {
+		s.CertFile, s.KeyFile = other.CertFile, other.KeyFile
+	}
+	if s.ServerName == "" {
+		s.ServerName = other.ServerName
+	}
+	return s
+}
+
+// Profile holds the settings of a named profile. Every profile has its own API key.
+type Profile struct {
+	Name string `yaml:"-"`
+	// The API endpoint, e.g. api.stellarstation.com:443. Empty means the default endpoint.
+	APIURL string      `yaml:"api-url,omitempty"`
+	TLS    TLSSettings `yaml:"tls,omitempty"`
+	// The command of the credential helper keeping the API key, if the key is kept by one.
+	CredentialHelper string `yaml:"credential-helper,omitempty"`
+	// Flag defaults of the profile, with the same keys as config.yaml.
+	Defaults Settings `yaml:"defaults,omitempty"`
+}
+
+// Profiles is the content of profiles.yaml in the configuration directory.
+type Profiles struct {
+	// The profile activated with `stellar auth use-profile`.
+	Active   string              `yaml:"active,omitempty"`
+	Profiles map[string]*Profile `yaml:"profiles,omitempty"`
+}
+
+// ValidateProfileName returns an error if the name cannot be used as a profile name.
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '-' and '_'", name)
+	}
+	return nil
+}
+
+// SelectProfile selects the profile to use for this invocation, overriding STELLAR_PROFILE and the active profile.
+// An empty name clears the selection.
+func SelectProfile(name string) {
+	selectedProfile = name
+}
+
+// LoadProfiles reads profiles.yaml from the configuration directory. A missing file is not an error.
+func LoadProfiles() (*Profiles, error) {
+	p := &Profiles{}
+	content, err := os.ReadFile(filepath.Join(GetConfigDir(), profilesFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read profiles: %w", err)
+	}
+	path := filepath.Join(GetConfigDir(), profilesFile)
+	if err := yaml.Unmarshal(content, p); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	for name, profile := range p.Profiles {
+		if err := ValidateProfileName(name); err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", path, err)
+		}
+		if profile == nil {
+			profile = &Profile{}
+			p.Profiles[name] = profile
+		}
+		profile.Name = name
+	}
+	return p, nil
+}
+
+// Save writes the profiles to profiles.yaml in the configuration directory.
+func (p *Profiles) Save() error {
+	content, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err := EnsureConfigDir(); err != nil {
+		return fmt.Errorf("could not create config directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(GetConfigDir(), profilesFile), content, 0600); err != nil {
+		return fmt.Errorf("could not write profiles: %w", err)
+	}
+	return nil
+}
+
+// Get returns the named profile, or nil if it has no settings. The default profile always exists.
+func (p *Profiles) Get(name string) *Profile {
+	if profile, ok := p.Profiles[name]; ok {
+		return profile
+	}
+	if name == DefaultProfile {
+		return &Profile{Name: DefaultProfile}
+	}
+	return nil
+}
+
+// GetOrCreate returns the named profile, adding it if it does not exist.
+func (p *Profiles) GetOrCreate(name string) *Profile {
+	if profile, ok := p.Profiles[name]; ok {
+		return profile
+	}
+	if p.Profiles == nil {
+		p.Profiles = make(map[string]*Profile)
+	}
+	profile := &Profile{Name: name}
+	p.Profiles[name] = profile
+	return profile
+}
+
+// Names returns the names of all profiles, including the default profile, sorted.
+func (p *Profiles) Names() []string {
+	names := []string{DefaultProfile}
+	for name := range p.Profiles {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CurrentName returns the name of the profile in use: the --profile flag, then STELLAR_PROFILE, then the active
+// profile, then the default profile. The name is used in paths, so an invalid name is an error.
+func (p *Profiles) CurrentName() (string, error) {
+	name := DefaultProfile
+	switch {
+	case selectedProfile != "":
+		name = selectedProfile
+	case os.Getenv(ProfileEnv) != "":
+		name = os.Getenv(ProfileEnv)
+	case p.Active != "":
+		name = p.Active
+	}
+	if err := ValidateProfileName(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// CurrentProfile returns the profile in use. A profile that has never been configured has no settings; the caller
+// finds out from the missing API key.
+func CurrentProfile() (*Profile, error) {
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return nil, err
+	}
+	name, err := profiles.CurrentName()
+	if err != nil {
+		return nil, err
+	}
+	if profile := profiles.Get(name); profile != nil {
+		return profile, nil
+	}
+	return &Profile{Name: name}, nil
+}
+
+// GetProfileDir returns the directory holding the files of the named profile. Files of the default profile are kept
+// directly in the configuration directory.
+func GetProfileDir(name string) string {
+	if name == DefaultProfile {
+		return GetConfigDir()
+	}
+	return filepath.Join(GetConfigDir(), "profiles", name)
+}
@@ -0,0 +1,140 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+func useTempHome(t *testing.T) {
+	homedir.DisableCache = true
+	t.Cleanup(func() { homedir.DisableCache = false })
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("APPDATA", t.TempDir())
+	t.Setenv(ProfileEnv, "")
+}
+
+func TestProfiles(t *testing.T) {
+	useTempHome(t)
+	t.Cleanup(func() { SelectProfile("") })
+
+	profiles, err := LoadProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := profiles.CurrentName(); name != DefaultProfile {
+		t.Errorf("current profile without configuration = %q, want %q", name, DefaultProfile)
+	}
+	if profiles.Get("staging") != nil {
+		t.Errorf("unknown profile should be nil")
+	}
+
+	staging := profiles.GetOrCreate("staging")
+	staging.APIURL = "api.staging.example:443"
+	staging.TLS.CAFile = "/etc/ca.pem"
+	profiles.Active = "staging"
+	if err := profiles.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err = LoadProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := profiles.Names(); len(got) != 2 || got[0] != DefaultProfile || got[1] != "staging" {
+		t.Errorf("names = %v", got)
+	}
+
+	current, err := CurrentProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Name != "staging" || current.APIURL != "api.staging.example:443" || current.TLS.CAFile != "/etc/ca.pem" {
+		t.Errorf("active profile = %+v", current)
+	}
+
+	t.Setenv(ProfileEnv, "prod")
+	if name, _ := profiles.CurrentName(); name != "prod" {
+		t.Errorf("%s should take precedence over the active profile, got %q", ProfileEnv, name)
+	}
+	SelectProfile(DefaultProfile)
+	if name, _ := profiles.CurrentName(); name != DefaultProfile {
+		t.Errorf("--profile should take precedence over %s, got %q", ProfileEnv, name)
+	}
+
+	if dir := GetProfileDir(DefaultProfile); dir != GetConfigDir() {
+		t.Errorf("default profile dir = %q", dir)
+	}
+	if dir := GetProfileDir("staging"); dir != filepath.Join(GetConfigDir(), "profiles", "staging") {
+		t.Errorf("staging profile dir = %q", dir)
+	}
+}
+
+func TestInvalidProfileName(t *testing.T) {
+	useTempHome(t)
+
+	profiles := &Profiles{Active: "../../etc"}
+	if _, err := profiles.CurrentName(); err == nil {
+		t.Errorf("invalid active profile should be rejected")
+	}
+	t.Setenv(ProfileEnv, "../outside")
+	if _, err := profiles.CurrentName(); err == nil {
+		t.Errorf("invalid %s should be rejected", ProfileEnv)
+	}
+	t.Setenv(ProfileEnv, "")
+	if _, err := CurrentProfile(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EnsureConfigDir(); err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("profiles:\n  ../outside:\n    api-url: example.com:443\n")
+	if err := os.WriteFile(filepath.Join(GetConfigDir(), profilesFile), content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadProfiles(); err == nil {
+		t.Errorf("profiles.yaml with an invalid profile name should be rejected")
+	}
+}
+
+func TestTLSSettingsMerge(t *testing.T) {
+	yes, no := true, false
+	profile := TLSSettings{Insecure: &yes, CAFile: "/etc/ca.pem"}
+
+	if merged := (TLSSettings{}).Merge(profile); !merged.IsInsecure() || merged.CAFile != "/etc/ca.pem" {
+		t.Errorf("unset settings should be taken from the profile, got %+v", merged)
+	}
+	if merged := (TLSSettings{Insecure: &no}).Merge(profile); merged.IsInsecure() {
+		t.Errorf("insecure=false should override the profile")
+	}
+}
+
+func TestValidateProfileName(t *testing.T) {
+	for _, name := range []string{"default", "staging-2", "org.prod", "a_b"} {
+		if err := ValidateProfileName(name); err != nil {
+			t.Errorf("%q: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "../etc", "a/b", ".hidden", "with space"} {
+		if err := ValidateProfileName(name); err == nil {
+			t.Errorf("%q should be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SettingsEnvPrefix is the prefix of the environment variables that set flag defaults, e.g. STELLAR_OUTPUT for
+// --output.
+const SettingsEnvPrefix = "STELLAR_"
+
+const settingsFile = "config.yaml"
+
+// Settings holds flag defaults keyed by flag name, e.g. "output", or by command path and flag name, e.g.
+// "satellite.open-stream.stats". A key with a command path only applies to that command and takes precedence over
+// the flag name alone. Values are given as on the command line.
+type Settings map[string]string
+
+// SettingKey returns the key of a flag of the command with the given path, e.g. ["satellite", "open-stream"].
+func SettingKey(commandPath []string, flag string) string {
+	return strings.Join(append(append([]string{}, commandPath...), flag), ".")
+}
+
+// SplitSettingKey splits a key into its command path and flag name.
+func SplitSettingKey(key string) ([]string, string) {
+	parts := strings.Split(key, ".")
+	return parts[:len(parts)-1], parts[len(parts)-1]
+}
+
+// SettingEnv returns the environment variable that sets the default of a flag.
+func SettingEnv(flag string) string {
+	return SettingsEnvPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// GetSettingsFile returns the path of config.yaml.
+func GetSettingsFile() string {
+	return filepath.Join(GetConfigDir(), settingsFile)
+}
+
+// LoadSettings reads config.yaml from the configuration directory. A missing file is not an error.
+func LoadSettings() (Settings, error) {
+	s := Settings{}
+	content, err := os.ReadFile(GetSettingsFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read settings: %w", err)
+	}
+	if err := yaml.Unmarshal(content, &s); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", GetSettingsFile(), err)
+	}
+	return s, nil
+}
+
+// Save writes the settings to config.yaml in the configuration directory.
+func (s Settings) Save() error {
+	content, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := EnsureConfigDir(); err != nil {
+		return fmt.Errorf("could not create config directory: %w", err)
+	}
+	if err := os.WriteFile(GetSettingsFile(), content, 0600); err != nil {
+		return fmt.Errorf("could not write settings: %w", err)
+	}
+	return nil
+}
+
+// Keys returns the keys of the settings, sorted.
+func (s Settings) Keys() []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Defaults resolves flag defaults from the environment, the profile in use and config.yaml, in that order.
+type Defaults struct {
+	profile  *Profile
+	settings Settings
+}
+
+// LoadDefaults loads the flag defaults of the profile in use and config.yaml.
+func LoadDefaults() (*Defaults, error) {
+	profile, err := CurrentProfile()
+	if err != nil {
+		return nil, err
+	}
+	settings, err := LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	return &Defaults{profile: profile, settings: settings}, nil
+}
+
+// Lookup returns the default of a flag of the command with the given path and where it was set, if any.
+func (d *Defaults) Lookup(commandPath []string, flag string) (value string, source string, ok bool) {
+	env := SettingEnv(flag)
+	if value := os.Getenv(env); value != "" {
+		return value, "$" + env, true
+	}
+
+	keys := []string{SettingKey(commandPath, flag), flag}
+	for _, key := range keys {
+		if value, ok := d.profile.Defaults[key]; ok {
+			return value, fmt.Sprintf("profile %s", d.profile.Name), true
+		}
+	}
+	for _, key := range keys {
+		if value, ok := d.settings[key]; ok {
+			return value, settingsFile, true
+		}
+	}
+	return "", "", false
+}
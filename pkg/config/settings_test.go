@@ -0,0 +1,91 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+)
+
+func TestDefaults(t *testing.T) {
+	useTempHome(t)
+	t.Setenv("STELLAR_OUTPUT", "")
+	t.Cleanup(func() { SelectProfile("") })
+
+	settings := Settings{
+		"output":                           "csv",
+		"min-elevation":                    "10",
+		"satellite.list-plans.output":      "wide",
+		"satellite.open-stream.stats":      "true",
+		"satellite.open-stream.udp-listen": "127.0.0.1",
+	}
+	if err := settings.Save(); err != nil {
+		t.Fatal(err)
+	}
+	profiles := &Profiles{}
+	profiles.GetOrCreate("staging").Defaults = Settings{"min-elevation": "20", "satellite.open-stream.udp-listen": "::1"}
+	if err := profiles.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	lookup := func(key string) (string, string) {
+		defaults, err := LoadDefaults()
+		if err != nil {
+			t.Fatal(err)
+		}
+		path, flag := SplitSettingKey(key)
+		value, source, ok := defaults.Lookup(path, flag)
+		if !ok {
+			return "", ""
+		}
+		return value, source
+	}
+	check := func(key, wantValue, wantSource string) {
+		t.Helper()
+		if value, source := lookup(key); value != wantValue || source != wantSource {
+			t.Errorf("%s = %q from %q, want %q from %q", key, value, source, wantValue, wantSource)
+		}
+	}
+
+	check("satellite.list-passes.output", "csv", "config.yaml")
+	check("satellite.list-plans.output", "wide", "config.yaml")
+	check("satellite.open-stream.stats", "true", "config.yaml")
+	check("satellite.list-plans.stats", "", "")
+	check("satellite.list-passes.min-elevation", "10", "config.yaml")
+
+	SelectProfile("staging")
+	check("satellite.list-passes.min-elevation", "20", "profile staging")
+	check("satellite.open-stream.udp-listen", "::1", "profile staging")
+	check("satellite.list-plans.output", "wide", "config.yaml")
+
+	t.Setenv("STELLAR_MIN_ELEVATION", "30")
+	check("satellite.list-passes.min-elevation", "30", "$STELLAR_MIN_ELEVATION")
+}
+
+func TestSettingKey(t *testing.T) {
+	key := SettingKey([]string{"satellite", "open-stream"}, "accepted-framing")
+	if key != "satellite.open-stream.accepted-framing" {
+		t.Errorf("key = %q", key)
+	}
+	path, flag := SplitSettingKey(key)
+	if len(path) != 2 || path[0] != "satellite" || path[1] != "open-stream" || flag != "accepted-framing" {
+		t.Errorf("split = %v %q", path, flag)
+	}
+	if path, flag := SplitSettingKey("output"); len(path) != 0 || flag != "output" {
+		t.Errorf("split = %v %q", path, flag)
+	}
+	if env := SettingEnv("accepted-framing"); env != "STELLAR_ACCEPTED_FRAMING" {
+		t.Errorf("env = %q", env)
+	}
+}
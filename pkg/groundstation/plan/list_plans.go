@@ -16,13 +16,13 @@ package plan
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/infostellarinc/go-stellarstation/api/v1/groundstation"
 	"github.com/infostellarinc/stellarcli/pkg/apiclient"
-	log "github.com/infostellarinc/stellarcli/pkg/logger"
 	"github.com/infostellarinc/stellarcli/util/printer"
 )
 
@@ -58,18 +58,16 @@ type ListOptions struct {
 }
 
 // ListPlans returns a list of plans for a given ground station.
-func ListPlans(o *ListOptions) {
-	conn, err := apiclient.Dial()
+func ListPlans(apiClient *apiclient.Client, o *ListOptions) error {
+	client, err := apiClient.GroundStationService()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer conn.Close()
 
 	aosAfterTimestamp := timestamppb.New(o.AOSAfter.UTC())
 
 	aosBeforeTimestamp := timestamppb.New(o.AOSBefore.UTC())
 
-	client := groundstation.NewGroundStationServiceClient(conn)
 	request := &groundstation.ListPlansRequest{
 		GroundStationId: o.ID,
 		AosAfter:        aosAfterTimestamp,
@@ -78,8 +76,7 @@ func ListPlans(o *ListOptions) {
 
 	result, err := client.ListPlans(context.Background(), request)
 	if err != nil {
-		log.Printf("could not list plans: %v\n", err)
-		return
+		return fmt.Errorf("could not list plans: %w", err)
 	}
 
 	targetTemplate := listPlansTemplate
@@ -119,4 +116,6 @@ func ListPlans(o *ListOptions) {
 		results = append(results, obj)
 	}
 	o.Printer.WriteWithTemplate(results, targetTemplate)
+
+	return nil
 }
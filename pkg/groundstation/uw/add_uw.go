@@ -21,7 +21,6 @@ import (
 
 	"github.com/infostellarinc/go-stellarstation/api/v1/groundstation"
 	"github.com/infostellarinc/stellarcli/pkg/apiclient"
-	log "github.com/infostellarinc/stellarcli/pkg/logger"
 	"github.com/infostellarinc/stellarcli/util/printer"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -34,17 +33,15 @@ type AddUWOptions struct {
 }
 
 // AddUW adds a new unavailability uw to a given ground station.
-func AddUW(o *AddUWOptions) {
-	conn, err := apiclient.Dial()
+func AddUW(apiClient *apiclient.Client, o *AddUWOptions) error {
+	client, err := apiClient.GroundStationService()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer conn.Close()
 
 	startTimeTimestamp := timestamppb.New(o.StartTime.UTC())
 	endTimeTimestamp := timestamppb.New(o.EndTime.UTC())
 
-	client := groundstation.NewGroundStationServiceClient(conn)
 	request := &groundstation.AddUnavailabilityWindowRequest{
 		GroundStationId: o.ID,
 		StartTime:       startTimeTimestamp,
@@ -53,11 +50,12 @@ func AddUW(o *AddUWOptions) {
 
 	result, err := client.AddUnavailabilityWindow(context.Background(), request)
 	if err != nil {
-		log.Printf("problem adding unavailability window: %v\n", err)
-		return
+		return fmt.Errorf("problem adding unavailability window: %w", err)
 	}
 
 	defer o.Printer.Flush()
 	message := fmt.Sprintf("Succeeded to add the unavailability window as: %s", result.WindowId)
 	o.Printer.Write([]interface{}{message})
+
+	return nil
 }
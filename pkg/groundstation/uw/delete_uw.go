@@ -20,7 +20,6 @@ import (
 
 	"github.com/infostellarinc/go-stellarstation/api/v1/groundstation"
 	"github.com/infostellarinc/stellarcli/pkg/apiclient"
-	log "github.com/infostellarinc/stellarcli/pkg/logger"
 	"github.com/infostellarinc/stellarcli/util/printer"
 )
 
@@ -30,25 +29,24 @@ type DeleteUWOptions struct {
 }
 
 // DeleteUW deletes the existing unavailability window.
-func DeleteUW(o *DeleteUWOptions) {
-	conn, err := apiclient.Dial()
+func DeleteUW(apiClient *apiclient.Client, o *DeleteUWOptions) error {
+	client, err := apiClient.GroundStationService()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer conn.Close()
 
-	client := groundstation.NewGroundStationServiceClient(conn)
 	request := &groundstation.DeleteUnavailabilityWindowRequest{
 		WindowId: o.WindowID,
 	}
 
 	_, err = client.DeleteUnavailabilityWindow(context.Background(), request)
 	if err != nil {
-		log.Printf("problem deleting unavailability window: %v\n", err)
-		return
+		return fmt.Errorf("problem deleting unavailability window: %w", err)
 	}
 
 	defer o.Printer.Flush()
 	message := fmt.Sprintf("Succeeded to delete the unavailability window: %s", o.WindowID)
 	o.Printer.Write([]interface{}{message})
+
+	return nil
 }
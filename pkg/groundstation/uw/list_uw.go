@@ -16,13 +16,13 @@ package uw
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/infostellarinc/go-stellarstation/api/v1/groundstation"
 	"github.com/infostellarinc/stellarcli/pkg/apiclient"
-	log "github.com/infostellarinc/stellarcli/pkg/logger"
 	"github.com/infostellarinc/stellarcli/util/printer"
 )
 
@@ -41,17 +41,15 @@ var headers = []interface{}{
 }
 
 // ListUW returns a list of unavailability windows for a given ground station.
-func ListUW(o *ListUWOptions) {
-	conn, err := apiclient.Dial()
+func ListUW(apiClient *apiclient.Client, o *ListUWOptions) error {
+	client, err := apiClient.GroundStationService()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer conn.Close()
 
 	startTimeTimestamp := timestamppb.New(o.StartTime.UTC())
 	endTimeTimestamp := timestamppb.New(o.EndTime.UTC())
 
-	client := groundstation.NewGroundStationServiceClient(conn)
 	request := &groundstation.ListUnavailabilityWindowsRequest{
 		GroundStationId: o.ID,
 		StartTime:       startTimeTimestamp,
@@ -60,8 +58,7 @@ func ListUW(o *ListUWOptions) {
 
 	result, err := client.ListUnavailabilityWindows(context.Background(), request)
 	if err != nil {
-		log.Printf("problem listing unavailability windows: %v\n", err)
-		return
+		return fmt.Errorf("problem listing unavailability windows: %w", err)
 	}
 
 	defer o.Printer.Flush()
@@ -79,4 +76,6 @@ func ListUW(o *ListUWOptions) {
 
 		o.Printer.Write(record)
 	}
+
+	return nil
 }
@@ -0,0 +1,171 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lock provides advisory locks between stellar processes, so that two processes do not stream from the same
+// satellite or reserve the same pass at once. A lock is a file locked by its owner for as long as the owner runs,
+// holding its process ID and host. The operating system releases the lock of a process that exits without releasing
+// it, so a lock file left behind is simply locked again.
+package lock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/infostellarinc/stellarcli/pkg/config"
+)
+
+// Number of attempts to lock the lock file when it is removed by its owner meanwhile.
+const acquireAttempts = 3
+
+// errLocked is returned by lockFile when another process holds the lock.
+var errLocked = errors.New("locked")
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// LockedError is returned when the lock is held by another running process.
+type LockedError struct {
+	Name string
+	PID  int
+	// The host of the process, if it is not this host.
+	Host string
+}
+
+func (e *LockedError) Error() string {
+	if e.PID == 0 {
+		// The owner has not written its process ID yet.
+		return fmt.Sprintf("another stellar process for %s is already running", e.Name)
+	}
+	owner := fmt.Sprintf("pid %d", e.PID)
+	if e.Host != "" {
+		owner += " on " + e.Host
+	}
+	return fmt.Sprintf("another stellar process for %s is already running (%s)", e.Name, owner)
+}
+
+// Lock is a lock held by this process.
+type Lock struct {
+	// The locked lock file, kept open until the lock is released.
+	file *os.File
+}
+
+// DefaultDir returns the directory of the lock files when none is configured.
+func DefaultDir() string {
+	return filepath.Join(config.GetConfigDir(), "locks")
+}
+
+// StreamName returns the name of the lock of a stream of the satellite. Streams of a plan of the satellite use the
+// same lock, as an unplanned stream receives the data of every plan.
+func StreamName(satelliteId string) string {
+	return "satellite " + satelliteId
+}
+
+// ReservationName returns the name of the lock of the reservation of a pass.
+func ReservationName(reservationToken string) string {
+	sum := sha256.Sum256([]byte(reservationToken))
+	return "reservation " + hex.EncodeToString(sum[:8])
+}
+
+// Acquire takes the named lock in the directory, creating the directory if needed. It fails with a LockedError if
+// another running process holds the lock.
+func Acquire(dir, name string) (*Lock, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create lock directory: %w", err)
+	}
+	path := filepath.Join(dir, unsafeNameChars.ReplaceAllString(name, "_")+".lock")
+
+	for attempt := 0; attempt < acquireAttempts; attempt++ {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("could not create lock %s: %w", path, err)
+		}
+		err = lockFile(f)
+		if errors.Is(err, errLocked) {
+			f.Close()
+			pid, host, _ := readOwner(path)
+			if host == hostname() {
+				host = ""
+			}
+			return nil, &LockedError{Name: name, PID: pid, Host: host}
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("could not lock %s: %w", path, err)
+		}
+
+		// The owner removes the file before unlocking it, in which case the removed file was locked.
+		if info, err := os.Stat(path); err != nil || !sameFile(f, info) {
+			f.Close()
+			continue
+		}
+		if err := writeOwner(f); err != nil {
+			_ = removeLockFile(f)
+			return nil, fmt.Errorf("could not write lock %s: %w", path, err)
+		}
+		return &Lock{file: f}, nil
+	}
+	return nil, fmt.Errorf("could not acquire lock %s", path)
+}
+
+// Release releases the lock and removes its file. Releasing a nil lock does nothing.
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+	return removeLockFile(l.file)
+}
+
+// Return whether the open file is the file described by info.
+func sameFile(f *os.File, info os.FileInfo) bool {
+	opened, err := f.Stat()
+	return err == nil && os.SameFile(opened, info)
+}
+
+// Replace the content of the locked file with the process ID and host of this process.
+func writeOwner(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.WriteAt([]byte(fmt.Sprintf("%d\n%s\n", os.Getpid(), hostname())), 0)
+	return err
+}
+
+// Return the process ID and host of the owner of the lock.
+func readOwner(path string) (int, string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, "", err
+	}
+	fields := strings.Split(strings.TrimSpace(string(content)), "\n")
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil || pid <= 0 {
+		return 0, "", fmt.Errorf("invalid process ID %q", fields[0])
+	}
+	host := ""
+	if len(fields) > 1 {
+		host = fields[1]
+	}
+	return pid, host, nil
+}
+
+func hostname() string {
+	name, _ := os.Hostname()
+	return name
+}
@@ -0,0 +1,130 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Write a lock file owned by the process on the host.
+func writeLock(t *testing.T, dir, name string, pid int, host string) string {
+	path := filepath.Join(dir, name+".lock")
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("%d\n%s\n", pid, host)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAcquire(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "locks")
+
+	l, err := Acquire(dir, "satellite 1 plan 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "satellite_1_plan_2.lock")
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("lock file not created: %v", err)
+	}
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("lock file not removed: %v", err)
+	}
+	if err := (*Lock)(nil).Release(); err != nil {
+		t.Errorf("releasing a nil lock: %v", err)
+	}
+}
+
+func TestAcquireHeldLock(t *testing.T) {
+	dir := t.TempDir()
+
+	// A lock file is only held while it is locked.
+	holder, err := Acquire(dir, "satellite 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Acquire(dir, "satellite 1")
+	var lockedErr *LockedError
+	if !errors.As(err, &lockedErr) {
+		t.Fatalf("expected a LockedError, got %v", err)
+	}
+	if lockedErr.PID != os.Getpid() {
+		t.Errorf("pid = %d, want %d", lockedErr.PID, os.Getpid())
+	}
+	expected := fmt.Sprintf("another stellar process for satellite 1 is already running (pid %d)", os.Getpid())
+	if err.Error() != expected {
+		t.Errorf("error = %q, want %q", err.Error(), expected)
+	}
+
+	if err := holder.Release(); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Acquire(dir, "satellite 1")
+	if err != nil {
+		t.Fatalf("released lock could not be acquired: %v", err)
+	}
+	defer l.Release()
+}
+
+func TestAcquireLeftOverLock(t *testing.T) {
+	dir := t.TempDir()
+	// The file of a process that exited without releasing its lock is no longer locked.
+	writeLock(t, dir, "satellite_1", 1234, hostname())
+
+	l, err := Acquire(dir, "satellite 1")
+	if err != nil {
+		t.Fatalf("left over lock was not acquired: %v", err)
+	}
+	defer l.Release()
+	content, err := os.ReadFile(filepath.Join(dir, "satellite_1.lock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := fmt.Sprintf("%d\n%s\n", os.Getpid(), hostname()); string(content) != expected {
+		t.Errorf("lock content = %q, want %q", content, expected)
+	}
+}
+
+func TestAcquireLockOfOtherHost(t *testing.T) {
+	dir := t.TempDir()
+	holder, err := Acquire(dir, "satellite 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer holder.Release()
+	writeLock(t, dir, "satellite_1", 1234, "other-host")
+
+	_, err = Acquire(dir, "satellite 1")
+	var lockedErr *LockedError
+	if !errors.As(err, &lockedErr) || lockedErr.Host != "other-host" {
+		t.Fatalf("expected a LockedError of other-host, got %v", err)
+	}
+}
+
+func TestNames(t *testing.T) {
+	if name := StreamName("5"); name != "satellite 5" {
+		t.Errorf("StreamName = %q", name)
+	}
+	if ReservationName("a") == ReservationName("b") {
+		t.Errorf("reservations of different passes should have different locks")
+	}
+}
@@ -27,7 +27,7 @@ var emitRateMillis = 2000
 var isVerbose = false
 var isDebug = false
 var isNewLine = true
-var lastThrottledLine *string
+var lastThrottledLine func()
 var throttleCheckSchedulerRunning = false
 var throttleSchedulerLock sync.Mutex
 
@@ -73,13 +73,17 @@ func Printf(format string, v ...interface{}) {
 	log.Printf(format, v...)
 }
 
-// PrintlnThrottled writes to stdout (via fmt) but is throttled by emitRateMillis; throttled messages are dropped
+// PrintlnThrottled logs a line at most once per emit rate; the last line suppressed is logged once the rate allows.
+// Like Printf, it writes to the output of the standard logger.
 func PrintlnThrottled(format string, v ...interface{}) {
 	if throttleCheck() {
 		lineCheck()
-		fmt.Printf(format+"\n", v...)
+		log.Printf(format, v...)
 	} else {
-		deferPrint(format+"\n", v...)
+		s := fmt.Sprintf(format, v...)
+		deferPrint(func() {
+			log.Print(s)
+		})
 	}
 }
 
@@ -139,10 +143,9 @@ func LastLine(format string, v ...interface{}) {
 	isNewLine = false
 }
 
-func deferPrint(format string, v ...interface{}) {
-	s := fmt.Sprintf(format, v...)
+func deferPrint(print func()) {
 	throttleSchedulerLock.Lock()
-	lastThrottledLine = &s
+	lastThrottledLine = print
 	throttleSchedulerLock.Unlock()
 
 	throttleSchedulerLock.Lock()
@@ -162,7 +165,7 @@ func scheduleDeferLogCheck() {
 		if lastThrottledLine != nil && throttleCheck() {
 			lineCheck()
 
-			fmt.Printf("%s", *lastThrottledLine)
+			lastThrottledLine()
 			lastThrottledLine = nil
 			throttleCheckSchedulerRunning = false
 
@@ -179,6 +182,9 @@ func LastLineThrottled(format string, v ...interface{}) {
 		LastLine(format, v...)
 		isNewLine = false
 	} else {
-		deferPrint("\r"+format+" ", v...)
+		s := fmt.Sprintf("\r"+format+" ", v...)
+		deferPrint(func() {
+			fmt.Print(s)
+		})
 	}
 }
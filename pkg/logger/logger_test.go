@@ -17,7 +17,12 @@ package logger
 // run with "go test -v" in this folder to see output
 
 import (
+	"bytes"
 	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -32,3 +37,37 @@ func TestThrottle(t *testing.T) {
 	fmt.Println("(99 should be printed below this line)")
 	time.Sleep(time.Duration(600) * time.Millisecond)
 }
+
+// buffer safe for the concurrent writes of the deferred log check
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func TestThrottledToLogOutput(t *testing.T) {
+	var b syncBuffer
+	log.SetOutput(&b)
+	defer log.SetOutput(os.Stderr)
+
+	SetEmitRateMillis(100)
+	time.Sleep(150 * time.Millisecond)
+	PrintlnThrottled("first %d", 1)
+	PrintlnThrottled("second %d", 2)
+	time.Sleep(300 * time.Millisecond)
+
+	if !strings.Contains(b.String(), "first 1\n") || !strings.Contains(b.String(), "second 2\n") {
+		t.Errorf("throttled lines not written to the log output: %q", b.String())
+	}
+}
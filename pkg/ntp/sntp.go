@@ -0,0 +1,120 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ntp estimates the offset of the local clock with a Simple Network Time Protocol (RFC 4330) query.
+package ntp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// DefaultPort is the port NTP servers listen on.
+	DefaultPort = "123"
+
+	packetSize = 48
+	// seconds from the NTP epoch (1900) to the Unix epoch (1970)
+	ntpEpochOffset = 2208988800
+
+	// leap indicator 0, version 4, mode 3 (client)
+	clientHeader = 0<<6 | 4<<3 | 3
+	modeServer   = 4
+)
+
+// Result is the outcome of a query.
+type Result struct {
+	// Offset to add to the local clock to get the server's time.
+	Offset time.Duration
+	// Round trip time of the query, excluding the server's processing time.
+	RTT time.Duration
+	// Stratum of the server.
+	Stratum uint8
+}
+
+// Query asks server, host or host:port, for the time and estimates the offset of the local clock.
+func Query(server string, timeout time.Duration) (*Result, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, DefaultPort)
+	}
+
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	request := make([]byte, packetSize)
+	request[0] = clientHeader
+	sentAt := time.Now()
+	putTimestamp(request[40:], sentAt)
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	response := make([]byte, packetSize)
+	n, err := conn.Read(response)
+	receivedAt := time.Now()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseResponse(request, response[:n], sentAt, receivedAt)
+}
+
+func parseResponse(request, response []byte, sentAt, receivedAt time.Time) (*Result, error) {
+	if len(response) < packetSize {
+		return nil, fmt.Errorf("short NTP response: %d bytes", len(response))
+	}
+	if mode := response[0] & 0x7; mode != modeServer {
+		return nil, fmt.Errorf("unexpected NTP mode: %d", mode)
+	}
+	stratum := response[1]
+	if stratum == 0 {
+		return nil, fmt.Errorf("NTP server refused the query: %s", string(response[12:16]))
+	}
+	if string(response[24:32]) != string(request[40:48]) {
+		return nil, errors.New("NTP response does not match the request")
+	}
+
+	serverReceivedAt := getTimestamp(response[32:])
+	serverSentAt := getTimestamp(response[40:])
+
+	return &Result{
+		Offset:  (serverReceivedAt.Sub(sentAt) + serverSentAt.Sub(receivedAt)) / 2,
+		RTT:     receivedAt.Sub(sentAt) - serverSentAt.Sub(serverReceivedAt),
+		Stratum: stratum,
+	}, nil
+}
+
+// write t as a 64-bit NTP timestamp
+func putTimestamp(b []byte, t time.Time) {
+	seconds := uint64(t.Unix() + ntpEpochOffset)
+	fraction := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	binary.BigEndian.PutUint64(b, seconds<<32|fraction)
+}
+
+// read a 64-bit NTP timestamp
+func getTimestamp(b []byte) time.Time {
+	v := binary.BigEndian.Uint64(b)
+	seconds := int64(v>>32) - ntpEpochOffset
+	nanos := (v & 0xffffffff) * uint64(time.Second) >> 32
+	return time.Unix(seconds, int64(nanos))
+}
@@ -0,0 +1,76 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ntp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// serve one query from a server whose clock is ahead of the local clock by skew
+func serveOnce(t *testing.T, skew time.Duration, stratum uint8) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		defer conn.Close()
+		request := make([]byte, packetSize)
+		_, addr, err := conn.ReadFrom(request)
+		if err != nil {
+			return
+		}
+		response := make([]byte, packetSize)
+		response[0] = 4<<3 | modeServer
+		response[1] = stratum
+		copy(response[24:32], request[40:48])
+		putTimestamp(response[32:], time.Now().Add(skew))
+		putTimestamp(response[40:], time.Now().Add(skew))
+		_, _ = conn.WriteTo(response, addr)
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestQuery(t *testing.T) {
+	result, err := Query(serveOnce(t, 2*time.Second, 2), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := result.Offset - 2*time.Second; d < -50*time.Millisecond || d > 50*time.Millisecond {
+		t.Fatalf("offset %v, expected about 2s", result.Offset)
+	}
+	if result.RTT < 0 || result.RTT > 50*time.Millisecond {
+		t.Fatalf("unexpected round trip time: %v", result.RTT)
+	}
+	if result.Stratum != 2 {
+		t.Fatalf("unexpected stratum: %d", result.Stratum)
+	}
+}
+
+func TestQueryKissOfDeath(t *testing.T) {
+	if _, err := Query(serveOnce(t, 0, 0), time.Second); err == nil {
+		t.Fatal("expected an error for stratum 0")
+	}
+}
+
+func TestTimestampRoundTrip(t *testing.T) {
+	now := time.Unix(1700000000, 123456789)
+	b := make([]byte, 8)
+	putTimestamp(b, now)
+	if d := getTimestamp(b).Sub(now); d < -time.Microsecond || d > time.Microsecond {
+		t.Fatalf("timestamp off by %v", d)
+	}
+}
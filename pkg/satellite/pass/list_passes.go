@@ -16,10 +16,10 @@ package pass
 
 import (
 	"context"
+	"fmt"
 
 	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
 	"github.com/infostellarinc/stellarcli/pkg/apiclient"
-	log "github.com/infostellarinc/stellarcli/pkg/logger"
 	"github.com/infostellarinc/stellarcli/util/printer"
 )
 
@@ -65,20 +65,17 @@ type ListAvailablePassesOptions struct {
 }
 
 // ListAvailablePasses returns a list of passes available for a given satellite.
-func ListAvailablePasses(o *ListAvailablePassesOptions) {
-	conn, err := apiclient.Dial()
+func ListAvailablePasses(apiClient *apiclient.Client, o *ListAvailablePassesOptions) error {
+	client, err := apiClient.Service()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer conn.Close()
 
-	client := stellarstation.NewStellarStationServiceClient(conn)
 	request := &stellarstation.ListUpcomingAvailablePassesRequest{SatelliteId: o.ID}
 
 	result, err := client.ListUpcomingAvailablePasses(context.Background(), request)
 	if err != nil {
-		log.Printf("problem fetching upcoming passes: %v", err)
-		return
+		return fmt.Errorf("problem fetching upcoming passes: %w", err)
 	}
 
 	targetTemplate := listPassesTemplate
@@ -142,4 +139,6 @@ func ListAvailablePasses(o *ListAvailablePassesOptions) {
 		}
 	}
 	o.Printer.WriteWithTemplate(results, targetTemplate)
+
+	return nil
 }
@@ -20,7 +20,6 @@ import (
 
 	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
 	"github.com/infostellarinc/stellarcli/pkg/apiclient"
-	log "github.com/infostellarinc/stellarcli/pkg/logger"
 	"github.com/infostellarinc/stellarcli/util/printer"
 )
 
@@ -30,25 +29,24 @@ type ReservePassOptions struct {
 }
 
 // ReservePass schedule a pass.
-func ReservePass(o *ReservePassOptions) {
-	conn, err := apiclient.Dial()
+func ReservePass(apiClient *apiclient.Client, o *ReservePassOptions) error {
+	client, err := apiClient.Service()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer conn.Close()
 
-	client := stellarstation.NewStellarStationServiceClient(conn)
 	request := &stellarstation.ReservePassRequest{
 		ReservationToken: o.ReservationToken,
 	}
 
 	result, err := client.ReservePass(context.Background(), request)
 	if err != nil {
-		log.Printf("problem reserving pass: %v\n", err)
-		return
+		return fmt.Errorf("problem reserving pass: %w", err)
 	}
 
 	defer o.Printer.Flush()
 	message := fmt.Sprintf("Succeeded to reserve the pass as: %s", result.Plan.Id)
 	o.Printer.Write([]interface{}{message})
+
+	return nil
 }
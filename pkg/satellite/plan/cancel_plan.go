@@ -20,7 +20,6 @@ import (
 
 	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
 	"github.com/infostellarinc/stellarcli/pkg/apiclient"
-	log "github.com/infostellarinc/stellarcli/pkg/logger"
 	"github.com/infostellarinc/stellarcli/util/printer"
 )
 
@@ -30,25 +29,24 @@ type CancelPlanOptions struct {
 }
 
 // CancelPlan cancels a plan.
-func CancelPlan(o *CancelPlanOptions) {
-	conn, err := apiclient.Dial()
+func CancelPlan(apiClient *apiclient.Client, o *CancelPlanOptions) error {
+	client, err := apiClient.Service()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer conn.Close()
 
-	client := stellarstation.NewStellarStationServiceClient(conn)
 	request := &stellarstation.CancelPlanRequest{
 		PlanId: o.PlanID,
 	}
 
 	_, err = client.CancelPlan(context.Background(), request)
 	if err != nil {
-		log.Printf("error cancelling plan: %v", err)
-		return
+		return fmt.Errorf("error cancelling plan: %w", err)
 	}
 
 	defer o.Printer.Flush()
 	message := fmt.Sprintf("Succeeded to cancel the plan: %s", request.PlanId)
 	o.Printer.Write([]interface{}{message})
+
+	return nil
 }
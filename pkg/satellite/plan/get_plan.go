@@ -0,0 +1,61 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
+)
+
+// Range of AOS times searched for a plan, relative to now.
+const (
+	getPlanSearchBefore = 24 * time.Hour
+	getPlanSearchAfter  = 30 * 24 * time.Hour
+)
+
+// GetPlan returns the plan of a satellite with the given ID. Only plans with AOS between one day ago and 30 days
+// from now are searched.
+func GetPlan(apiClient *apiclient.Client, satelliteId, planId string) (*stellarstation.Plan, error) {
+	client, err := apiClient.Service()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	request := &stellarstation.ListPlansRequest{
+		SatelliteId: satelliteId,
+		AosAfter:    timestamppb.New(now.Add(-getPlanSearchBefore)),
+		AosBefore:   timestamppb.New(now.Add(getPlanSearchAfter)),
+	}
+
+	result, err := client.ListPlans(context.Background(), request)
+	if err != nil {
+		return nil, fmt.Errorf("error listing plans: %w", err)
+	}
+
+	for _, plan := range result.Plan {
+		if plan.Id == planId {
+			return plan, nil
+		}
+	}
+
+	return nil, apiclient.NotFoundError("plan %s not found for satellite %s", planId, satelliteId)
+}
@@ -16,13 +16,13 @@ package plan
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
 	"github.com/infostellarinc/stellarcli/pkg/apiclient"
-	log "github.com/infostellarinc/stellarcli/pkg/logger"
 	"github.com/infostellarinc/stellarcli/util/printer"
 )
 
@@ -68,17 +68,15 @@ type ListOptions struct {
 }
 
 // ListPlans returns a list of plans for a given satellite.
-func ListPlans(o *ListOptions) {
-	conn, err := apiclient.Dial()
+func ListPlans(apiClient *apiclient.Client, o *ListOptions) error {
+	client, err := apiClient.Service()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer conn.Close()
 
 	aosAfterTimestamp := timestamppb.New(o.AOSAfter.UTC())
 	aosBeforeTimestamp := timestamppb.New(o.AOSBefore.UTC())
 
-	client := stellarstation.NewStellarStationServiceClient(conn)
 	request := &stellarstation.ListPlansRequest{
 		SatelliteId: o.ID,
 		AosAfter:    aosAfterTimestamp,
@@ -87,8 +85,7 @@ func ListPlans(o *ListOptions) {
 
 	result, err := client.ListPlans(context.Background(), request)
 	if err != nil {
-		log.Printf("error listing plans: %v", err)
-		return
+		return fmt.Errorf("error listing plans: %w", err)
 	}
 
 	targetTemplate := listPlansTemplate
@@ -158,4 +155,6 @@ func ListPlans(o *ListOptions) {
 		results = append(results, obj)
 	}
 	o.Printer.WriteWithTemplate(results, targetTemplate)
+
+	return nil
 }
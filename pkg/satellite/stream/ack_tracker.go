@@ -0,0 +1,134 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"sync"
+
+	log "github.com/infostellarinc/stellarcli/pkg/logger"
+)
+
+// ackGroup is the set of frames received in one ReceiveTelemetryResponse.
+type ackGroup struct {
+	ackId     string
+	remaining int
+	// Set when a frame of the message could not be delivered.
+	failed bool
+}
+
+// ackTracker delays acknowledgements until every frame of a message has been delivered. Acknowledgements are
+// sent in the order the messages were received, so a message is only acknowledged once all earlier messages are.
+type ackTracker struct {
+	mu      sync.Mutex
+	pending []*ackGroup
+	// IDs of the completed messages waiting to be acknowledged, in order.
+	ready []string
+	// Held while sending, so that acknowledgements are sent in order without holding mu.
+	sendMu sync.Mutex
+	send   func(ackId string)
+}
+
+func newAckTracker(send func(ackId string)) *ackTracker {
+	return &ackTracker{send: send}
+}
+
+// begin registers a message. The returned group holds one reference which must be released with done once all
+// of its frames have been added.
+func (t *ackTracker) begin(ackId string) *ackGroup {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	g := &ackGroup{ackId: ackId, remaining: 1}
+	t.pending = append(t.pending, g)
+	return g
+}
+
+// add registers a frame belonging to the group.
+func (t *ackTracker) add(g *ackGroup) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	g.remaining++
+}
+
+// done releases one reference to the group and acknowledges every completed message at the head of the queue.
+func (t *ackTracker) done(g *ackGroup) {
+	t.release(g, false)
+}
+
+// fail releases one reference to the group like done, but the message is never acknowledged.
+func (t *ackTracker) fail(g *ackGroup) {
+	t.release(g, true)
+}
+
+func (t *ackTracker) release(g *ackGroup, failed bool) {
+	t.mu.Lock()
+	g.remaining--
+	if failed {
+		g.failed = true
+	}
+	for len(t.pending) > 0 && t.pending[0].remaining <= 0 {
+		head := t.pending[0]
+		t.pending[0] = nil
+		t.pending = t.pending[1:]
+		if head.failed {
+			log.Debug("not acknowledging undelivered message: %v", head.ackId)
+			continue
+		}
+		t.ready = append(t.ready, head.ackId)
+	}
+	t.mu.Unlock()
+
+	t.flush()
+}
+
+// flush sends the acknowledgements of the completed messages. Sending may block, so it is done without holding mu,
+// which would stall the receive loop registering new messages.
+func (t *ackTracker) flush() {
+	t.sendMu.Lock()
+	defer t.sendMu.Unlock()
+
+	for {
+		t.mu.Lock()
+		if len(t.ready) == 0 {
+			t.mu.Unlock()
+			return
+		}
+		ackId := t.ready[0]
+		t.ready = t.ready[1:]
+		t.mu.Unlock()
+
+		t.send(ackId)
+	}
+}
+
+// frame creates a frame for data that releases its reference to the group when delivered.
+func (t *ackTracker) frame(g *ackGroup, data []byte) *Frame {
+	t.add(g)
+	var once sync.Once
+	return &Frame{
+		Data: data,
+		done: func() {
+			once.Do(func() {
+				t.done(g)
+			})
+		},
+		fail: func() {
+			once.Do(func() {
+				t.fail(g)
+			})
+		},
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAckAfterDelivery(t *testing.T) {
+	var acked []string
+	tracker := newAckTracker(func(ackId string) {
+		acked = append(acked, ackId)
+	})
+
+	g1 := tracker.begin("ack1")
+	f1 := tracker.frame(g1, []byte("a"))
+	f2 := tracker.frame(g1, []byte("b"))
+	tracker.done(g1)
+
+	g2 := tracker.begin("ack2")
+	f3 := tracker.frame(g2, []byte("c"))
+	tracker.done(g2)
+
+	// Nothing is acknowledged before delivery.
+	assertEqual(t, len(acked), 0, "")
+
+	// A later message is not acknowledged before an earlier one.
+	f3.Done()
+	assertEqual(t, len(acked), 0, "")
+
+	f1.Done()
+	f1.Done()
+	assertEqual(t, len(acked), 0, "")
+
+	f2.Done()
+	assertEqual(t, strings.Join(acked, ","), "ack1,ack2", "")
+}
+
+func TestAckWithoutFrames(t *testing.T) {
+	var acked []string
+	tracker := newAckTracker(func(ackId string) {
+		acked = append(acked, ackId)
+	})
+
+	tracker.done(tracker.begin("ack1"))
+	assertEqual(t, strings.Join(acked, ","), "ack1", "")
+}
+
+func TestNoAckForFailedFrame(t *testing.T) {
+	var acked []string
+	tracker := newAckTracker(func(ackId string) {
+		acked = append(acked, ackId)
+	})
+
+	g1 := tracker.begin("ack1")
+	f1 := tracker.frame(g1, []byte("a"))
+	f2 := tracker.frame(g1, []byte("b"))
+	tracker.done(g1)
+
+	g2 := tracker.begin("ack2")
+	f3 := tracker.frame(g2, []byte("c"))
+	tracker.done(g2)
+
+	f1.Fail()
+	// A failed frame cannot be delivered later.
+	f1.Done()
+	f2.Done()
+	assertEqual(t, len(acked), 0, "")
+
+	f3.Done()
+	assertEqual(t, strings.Join(acked, ","), "ack2", "")
+}
+
+func TestAckSendDoesNotBlockBegin(t *testing.T) {
+	sent, unblock := make(chan string, 2), make(chan struct{})
+	tracker := newAckTracker(func(ackId string) {
+		sent <- ackId
+		if ackId == "ack1" {
+			<-unblock
+		}
+	})
+
+	go tracker.done(tracker.begin("ack1"))
+	assertEqual(t, <-sent, "ack1", "")
+
+	// The receive loop registers new messages while an acknowledgement is being sent.
+	begun := make(chan *ackGroup)
+	go func() { begun <- tracker.begin("ack2") }()
+	var g2 *ackGroup
+	select {
+	case g2 = <-begun:
+	case <-time.After(5 * time.Second):
+		t.Fatal("begin blocked while an acknowledgement was being sent")
+	}
+
+	go tracker.done(g2)
+	close(unblock)
+	assertEqual(t, <-sent, "ack2", "")
+}
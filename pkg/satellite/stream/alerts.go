@@ -0,0 +1,351 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
+
+	log "github.com/infostellarinc/stellarcli/pkg/logger"
+)
+
+// Alert rules.
+const (
+	// No telemetry for longer than AlertRules.NoData while the satellite is in view.
+	AlertRuleNoData = "no_data"
+	// The 90th percentile of the telemetry delay of the plan is above AlertRules.DelayP90.
+	AlertRuleDelayP90 = "delay_p90"
+	// The instantaneous data rate is below AlertRules.MinRatePercent of the downlink bitrate of the plan.
+	AlertRuleLowRate = "low_rate"
+	// The stream reconnected to the API more than AlertRules.MaxReconnects times during the plan.
+	AlertRuleReconnects = "reconnects"
+)
+
+// States of an alert.
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// alertInterval - Interval between evaluations of the alert rules
+const alertInterval = time.Second
+
+// alertActionTimeout - Maximum time an alert command or webhook may take
+const alertActionTimeout = 30 * time.Second
+
+// AlertRules holds the thresholds of the alert rules. A zero threshold disables its rule.
+type AlertRules struct {
+	NoData         time.Duration
+	DelayP90       time.Duration
+	MinRatePercent float64
+	MaxReconnects  int64
+}
+
+// AlertOptions configures alerts raised while streaming and where they are delivered.
+type AlertOptions struct {
+	Rules AlertRules
+
+	// Command run through the shell for every alert, with the alert as JSON on standard input.
+	Command string
+	// URL the alert is POSTed to as JSON.
+	WebhookURL string
+	// File alerts are appended to as JSON Lines.
+	File string
+
+	// Looks up a plan of the satellite for its AOS and LOS times and downlink bitrate. Rules that need them are
+	// skipped when it is nil or fails.
+	PlanLookup func(planId string) (*stellarstation.Plan, error)
+}
+
+// Alert is raised when a rule starts or stops firing.
+type Alert struct {
+	Time        time.Time `json:"time"`
+	Rule        string    `json:"rule"`
+	State       string    `json:"state"`
+	SatelliteID string    `json:"satellite_id"`
+	PlanID      string    `json:"plan_id"`
+	StreamID    string    `json:"stream_id"`
+	Message     string    `json:"message"`
+}
+
+// alertMonitor periodically evaluates the alert rules against the statistics of a stream.
+type alertMonitor struct {
+	o           *AlertOptions
+	satelliteId string
+	metrics     *MetricsCollector
+	// returns the IDs of the current plan and stream
+	ids func() (string, string)
+
+	// plans looked up so far, nil for failed lookups; plansLock guards it against the lookup goroutines
+	plans     map[string]*stellarstation.Plan
+	plansLock sync.Mutex
+
+	planId   string
+	firing   map[string]bool
+	messages map[string]string
+	fileLock sync.Mutex
+
+	stopChan    chan struct{}
+	stoppedChan chan struct{}
+	stopOnce    sync.Once
+}
+
+func startAlertMonitor(o *AlertOptions, satelliteId string, metrics *MetricsCollector, ids func() (string, string)) *alertMonitor {
+	m := newAlertMonitor(o, satelliteId, metrics, ids)
+	go m.run()
+	return m
+}
+
+func newAlertMonitor(o *AlertOptions, satelliteId string, metrics *MetricsCollector, ids func() (string, string)) *alertMonitor {
+	return &alertMonitor{
+		o:           o,
+		satelliteId: satelliteId,
+		metrics:     metrics,
+		ids:         ids,
+		plans:       make(map[string]*stellarstation.Plan),
+		firing:      make(map[string]bool),
+		messages:    make(map[string]string),
+		stopChan:    make(chan struct{}),
+		stoppedChan: make(chan struct{}),
+	}
+}
+
+func (m *alertMonitor) run() {
+	defer close(m.stoppedChan)
+
+	ticker := time.NewTicker(alertInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			m.evaluate(now)
+		case <-m.stopChan:
+			return
+		}
+	}
+}
+
+// stop evaluating the rules. Safe to call more than once and on a nil monitor.
+func (m *alertMonitor) stop() {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+	})
+	<-m.stoppedChan
+}
+
+// plan returns the plan with the given ID, or nil while it is being looked up or if the lookup failed
+func (m *alertMonitor) plan(planId string) *stellarstation.Plan {
+	if m.o.PlanLookup == nil {
+		return nil
+	}
+	m.plansLock.Lock()
+	defer m.plansLock.Unlock()
+	if plan, ok := m.plans[planId]; ok {
+		return plan
+	}
+	m.plans[planId] = nil
+	// Look up in the background so that a slow API never delays the other rules or stopping the monitor.
+	go func() {
+		plan, err := m.o.PlanLookup(planId)
+		if err != nil {
+			log.Printf("could not get plan %s for alerts: %v\n", planId, err)
+			return
+		}
+		m.plansLock.Lock()
+		defer m.plansLock.Unlock()
+		m.plans[planId] = plan
+	}()
+	return nil
+}
+
+func (m *alertMonitor) evaluate(now time.Time) {
+	planId, streamId := m.ids()
+	if planId != m.planId {
+		// alerts of the previous plan no longer apply
+		for rule := range m.firing {
+			m.set(rule, false, "plan ended", streamId)
+		}
+		m.planId = planId
+	}
+	if planId == "" {
+		return
+	}
+
+	s := m.metrics.snapshot()
+	if s.planId != planId {
+		// no telemetry received for the plan yet
+		s = metricsSnapshot{planId: planId}
+	}
+	rules := m.o.Rules
+	plan := m.plan(planId)
+	inView := false
+	var aos time.Time
+	if plan != nil && plan.AosTime != nil && plan.LosTime != nil {
+		aos = plan.AosTime.AsTime()
+		inView = !now.Before(aos) && now.Before(plan.LosTime.AsTime())
+	}
+
+	if rules.NoData > 0 {
+		since := aos
+		if s.lastReceived.After(since) {
+			since = s.lastReceived
+		}
+		silent := now.Sub(since)
+		m.set(AlertRuleNoData, inView && silent > rules.NoData,
+			fmt.Sprintf("no data for %s after AOS at %s", silent.Truncate(time.Second), aos.UTC().Format(time.RFC3339)), streamId)
+	}
+	if rules.DelayP90 > 0 {
+		p90 := time.Duration(s.delayP90)
+		m.set(AlertRuleDelayP90, p90 > rules.DelayP90,
+			fmt.Sprintf("delay p90 %s is above %s", p90.Truncate(time.Millisecond), rules.DelayP90), streamId)
+	}
+	if rules.MinRatePercent > 0 {
+		bitrate := plan.GetChannelSet().GetDownlink().GetBitrate()
+		minRate := int64(float64(bitrate) * rules.MinRatePercent / 100)
+		m.set(AlertRuleLowRate, inView && bitrate > 0 && s.instantRate > 0 && s.instantRate < minRate,
+			fmt.Sprintf("rate %sbps is below %g%% of the downlink bitrate %sbps", humanReadableCountSI(s.instantRate),
+				rules.MinRatePercent, humanReadableCountSI(int64(bitrate))), streamId)
+	}
+	if rules.MaxReconnects > 0 {
+		m.set(AlertRuleReconnects, s.reconnects > rules.MaxReconnects,
+			fmt.Sprintf("%d reconnects, more than %d", s.reconnects, rules.MaxReconnects), streamId)
+	}
+}
+
+// raise an alert when a rule starts or stops firing
+func (m *alertMonitor) set(rule string, firing bool, message, streamId string) {
+	if firing == m.firing[rule] {
+		return
+	}
+	if firing {
+		m.firing[rule] = true
+		m.messages[rule] = message
+	} else {
+		delete(m.firing, rule)
+		message = m.messages[rule]
+		delete(m.messages, rule)
+	}
+
+	state := AlertResolved
+	if firing {
+		state = AlertFiring
+	}
+	m.notify(&Alert{
+		Time:        time.Now().UTC(),
+		Rule:        rule,
+		State:       state,
+		SatelliteID: m.satelliteId,
+		PlanID:      m.planId,
+		StreamID:    streamId,
+		Message:     message,
+	})
+}
+
+// deliver the alert to every configured destination
+func (m *alertMonitor) notify(a *Alert) {
+	log.Printf("[ALERT] %s %s: %s (plan %s)\n", a.Rule, a.State, a.Message, a.PlanID)
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		log.Printf("could not encode alert: %v\n", err)
+		return
+	}
+
+	if m.o.File != "" {
+		if err := m.appendToFile(data); err != nil {
+			log.Printf("could not write alert to %s: %v\n", m.o.File, err)
+		}
+	}
+	// Commands and webhooks may be slow; run them in the background so that rules keep being evaluated.
+	if m.o.Command != "" {
+		go func() {
+			if err := runAlertCommand(m.o.Command, a, data); err != nil {
+				log.Printf("alert command failed: %v\n", err)
+			}
+		}()
+	}
+	if m.o.WebhookURL != "" {
+		go func() {
+			if err := postAlert(m.o.WebhookURL, data); err != nil {
+				log.Printf("could not post alert: %v\n", err)
+			}
+		}()
+	}
+}
+
+func (m *alertMonitor) appendToFile(data []byte) error {
+	m.fileLock.Lock()
+	defer m.fileLock.Unlock()
+
+	f, err := os.OpenFile(m.o.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// run command through the shell with the alert as JSON on stdin and its fields in STELLAR_ALERT_* variables
+func runAlertCommand(command string, a *Alert, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), alertActionTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"STELLAR_ALERT_RULE="+a.Rule,
+		"STELLAR_ALERT_STATE="+a.State,
+		"STELLAR_ALERT_SATELLITE_ID="+a.SatelliteID,
+		"STELLAR_ALERT_PLAN_ID="+a.PlanID,
+		"STELLAR_ALERT_STREAM_ID="+a.StreamID,
+		"STELLAR_ALERT_MESSAGE="+a.Message,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+func postAlert(url string, data []byte) error {
+	client := &http.Client{Timeout: alertActionTimeout}
+	response, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected response from %s: %s", url, response.Status)
+	}
+	return nil
+}
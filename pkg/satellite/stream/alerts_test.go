@@ -0,0 +1,99 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
+	"github.com/infostellarinc/go-stellarstation/api/v1/radio"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func readAlerts(t *testing.T, path string) []Alert {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var alerts []Alert
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var a Alert
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			t.Fatal(err)
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts
+}
+
+func TestAlerts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+	now := time.Now()
+	plan := &stellarstation.Plan{
+		Id:         "plan1",
+		AosTime:    timestamppb.New(now.Add(-time.Minute)),
+		LosTime:    timestamppb.New(now.Add(time.Minute)),
+		ChannelSet: &stellarstation.ChannelSet{Downlink: &radio.RadioDeviceConfiguration{Bitrate: 9600}},
+	}
+	o := &AlertOptions{
+		Rules: AlertRules{
+			NoData:         30 * time.Second,
+			MinRatePercent: 50,
+			MaxReconnects:  1,
+		},
+		File: path,
+		PlanLookup: func(planId string) (*stellarstation.Plan, error) {
+			return plan, nil
+		},
+	}
+	metrics := NewMetricsCollector(nil)
+	planId := "plan1"
+	m := newAlertMonitor(o, "sat1", metrics, func() (string, string) { return planId, "stream1" })
+	m.plans["plan1"] = plan
+
+	// No telemetry a minute after AOS.
+	m.evaluate(now)
+	metrics.setPlanId("plan1")
+	metrics.collectReconnect()
+	metrics.collectReconnect()
+	m.evaluate(now)
+	// Telemetry arrives and the plan ends.
+	metrics.collectTelemetry("gs_1", createTelemetry(&now, 100))
+	m.evaluate(now)
+	planId = "plan2"
+	m.evaluate(now)
+
+	alerts := readAlerts(t, path)
+	assertEqual(t, len(alerts), 4, "")
+	assertEqual(t, alerts[0].Rule, AlertRuleNoData, "")
+	assertEqual(t, alerts[0].State, AlertFiring, "")
+	assertEqual(t, alerts[0].PlanID, "plan1", "")
+	assertEqual(t, alerts[0].StreamID, "stream1", "")
+	assertEqual(t, alerts[1].Rule, AlertRuleReconnects, "")
+	assertEqual(t, alerts[1].State, AlertFiring, "")
+	assertEqual(t, alerts[2].Rule, AlertRuleNoData, "")
+	assertEqual(t, alerts[2].State, AlertResolved, "")
+	assertEqual(t, alerts[3].Rule, AlertRuleReconnects, "")
+	assertEqual(t, alerts[3].State, AlertResolved, "")
+	assertEqual(t, alerts[3].PlanID, "plan1", "")
+}
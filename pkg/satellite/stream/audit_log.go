@@ -0,0 +1,119 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// Outcomes of an audited command.
+const (
+	CommandSent    = "sent"
+	CommandFailed  = "failed"
+	CommandDropped = "dropped"
+)
+
+// Kinds of audited requests.
+const (
+	AuditKindCommand       = "command"
+	AuditKindConfiguration = "configuration"
+)
+
+// CommandAuditRecord is one entry of the command audit log.
+type CommandAuditRecord struct {
+	Time        time.Time `json:"time"`
+	Kind        string    `json:"kind"`
+	SatelliteID string    `json:"satellite_id"`
+	PlanID      string    `json:"plan_id"`
+	StreamID    string    `json:"stream_id"`
+	Source      string    `json:"source"`
+	Length      int       `json:"length"`
+	SHA256      string    `json:"sha256"`
+	Payload     []byte    `json:"payload,omitempty"`
+	Outcome     string    `json:"outcome"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// CommandAuditLog records every request sent to a satellite as JSON Lines in an append-only file.
+type CommandAuditLog struct {
+	mu             sync.Mutex
+	file           *os.File
+	includePayload bool
+}
+
+// OpenCommandAuditLog opens the audit log at path for appending, creating it if needed. When includePayload is
+// set, records hold the payload itself in addition to its hash.
+func OpenCommandAuditLog(path string, includePayload bool) (*CommandAuditLog, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CommandAuditLog{
+		file:           f,
+		includePayload: includePayload,
+	}, nil
+}
+
+// Record appends a record for payload and syncs it to disk. Time, length and hash are filled in from payload;
+// sendErr sets the outcome to failed unless the outcome is already set.
+func (l *CommandAuditLog) Record(r *CommandAuditRecord, payload []byte, sendErr error) error {
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	r.Time = r.Time.UTC()
+	if r.Kind == "" {
+		r.Kind = AuditKindCommand
+	}
+	r.Length = len(payload)
+	sum := sha256.Sum256(payload)
+	r.SHA256 = hex.EncodeToString(sum[:])
+	if l.includePayload {
+		r.Payload = payload
+	}
+	if sendErr != nil {
+		r.Error = sendErr.Error()
+		if r.Outcome == "" {
+			r.Outcome = CommandFailed
+		}
+	} else if r.Outcome == "" {
+		r.Outcome = CommandSent
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+// Close closes the audit log.
+func (l *CommandAuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
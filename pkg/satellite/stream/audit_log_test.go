@@ -0,0 +1,78 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readAuditLog(t *testing.T, path string) []CommandAuditRecord {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var records []CommandAuditRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r CommandAuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestCommandAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := OpenCommandAuditLog(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = l.Record(&CommandAuditRecord{SatelliteID: "sat", Source: "tcp:127.0.0.1:5000"}, []byte("abc"), nil)
+	_ = l.Record(&CommandAuditRecord{SatelliteID: "sat"}, []byte("abc"), errors.New("stream closed"))
+	_ = l.Close()
+
+	// Records are appended to an existing log.
+	l, err = OpenCommandAuditLog(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = l.Record(&CommandAuditRecord{SatelliteID: "sat", Outcome: CommandDropped}, []byte("abc"), nil)
+	_ = l.Close()
+
+	records := readAuditLog(t, path)
+	assertEqual(t, len(records), 3, "")
+
+	assertEqual(t, records[0].Kind, AuditKindCommand, "")
+	assertEqual(t, records[0].Source, "tcp:127.0.0.1:5000", "")
+	assertEqual(t, records[0].Length, 3, "")
+	assertEqual(t, records[0].SHA256, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", "")
+	assertEqual(t, records[0].Payload == nil, true, "")
+	assertEqual(t, records[0].Outcome, CommandSent, "")
+
+	assertEqual(t, records[1].Outcome, CommandFailed, "")
+	assertEqual(t, records[1].Error, "stream closed", "")
+
+	assertEqual(t, records[2].Outcome, CommandDropped, "")
+	assertEqual(t, string(records[2].Payload), "abc", "")
+}
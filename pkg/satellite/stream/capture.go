@@ -0,0 +1,178 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// A capture file starts with captureMagic and the length-prefixed satellite ID, followed by one record per stream
+// response: the local receive time in Unix nanoseconds, the length of the response and the response as protobuf.
+// All integers are big-endian.
+var captureMagic = []byte("STLRCAP1")
+
+const (
+	captureRecordHeaderSize = 8 + 4
+	// maxCaptureRecordSize - Largest record accepted when reading, to fail fast on corrupt files
+	maxCaptureRecordSize = 64 << 20
+)
+
+// ErrNotCaptureFile is returned when reading a file that does not start with the capture file header.
+var ErrNotCaptureFile = errors.New("not a capture file")
+
+// CaptureWriter appends the telemetry responses of a stream to a capture file, preserving the plan, ground
+// station, framing and timestamps of every frame for offline analysis.
+type CaptureWriter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// CreateCaptureFile opens a capture file for the satellite, creating it if it does not exist and appending to it if
+// it does.
+func CreateCaptureFile(path, satelliteId string) (*CaptureWriter, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.Size() == 0 {
+		header := make([]byte, 0, len(captureMagic)+2+len(satelliteId))
+		header = append(header, captureMagic...)
+		header = binary.BigEndian.AppendUint16(header, uint16(len(satelliteId)))
+		header = append(header, satelliteId...)
+		if _, err := file.Write(header); err != nil {
+			file.Close()
+			return nil, err
+		}
+	} else if _, err := readCaptureHeader(bufio.NewReader(io.NewSectionReader(file, 0, info.Size()))); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("cannot append to %s: %w", path, err)
+	}
+
+	return &CaptureWriter{file: file}, nil
+}
+
+// Write appends a stream response received at receivedAt. Each record is written with a single write so that a
+// crash leaves at most one incomplete record at the end of the file.
+func (c *CaptureWriter) Write(receivedAt time.Time, response *stellarstation.SatelliteStreamResponse) error {
+	data, err := proto.Marshal(protoadapt.MessageV2Of(response))
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, captureRecordHeaderSize, captureRecordHeaderSize+len(data))
+	binary.BigEndian.PutUint64(record, uint64(receivedAt.UnixNano()))
+	binary.BigEndian.PutUint32(record[8:], uint32(len(data)))
+	record = append(record, data...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.file.Write(record)
+	return err
+}
+
+// Close closes the capture file.
+func (c *CaptureWriter) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.file.Close()
+}
+
+// CaptureRecord is a stream response read from a capture file.
+type CaptureRecord struct {
+	ReceivedAt time.Time
+	Response   *stellarstation.SatelliteStreamResponse
+}
+
+// CaptureReader reads the records of a capture file in order.
+type CaptureReader struct {
+	r           *bufio.Reader
+	SatelliteID string
+}
+
+// NewCaptureReader reads the header of a capture file. It returns ErrNotCaptureFile if r does not hold one.
+func NewCaptureReader(r io.Reader) (*CaptureReader, error) {
+	br := bufio.NewReader(r)
+	satelliteId, err := readCaptureHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	return &CaptureReader{r: br, SatelliteID: satelliteId}, nil
+}
+
+func readCaptureHeader(r *bufio.Reader) (string, error) {
+	magic, err := r.Peek(len(captureMagic))
+	if err != nil || !bytes.Equal(magic, captureMagic) {
+		return "", ErrNotCaptureFile
+	}
+	_, _ = r.Discard(len(captureMagic))
+
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return "", ErrNotCaptureFile
+	}
+	satelliteId := make([]byte, length)
+	if _, err := io.ReadFull(r, satelliteId); err != nil {
+		return "", ErrNotCaptureFile
+	}
+	return string(satelliteId), nil
+}
+
+// Next returns the next record. It returns io.EOF at the end of the file and io.ErrUnexpectedEOF if the file ends
+// with an incomplete record.
+func (c *CaptureReader) Next() (*CaptureRecord, error) {
+	header := make([]byte, captureRecordHeaderSize)
+	if _, err := io.ReadFull(c.r, header); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header[8:])
+	if length > maxCaptureRecordSize {
+		return nil, fmt.Errorf("corrupt capture record of %d bytes", length)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	response := &stellarstation.SatelliteStreamResponse{}
+	if err := proto.Unmarshal(data, protoadapt.MessageV2Of(response)); err != nil {
+		return nil, fmt.Errorf("corrupt capture record: %w", err)
+	}
+
+	return &CaptureRecord{
+		ReceivedAt: time.Unix(0, int64(binary.BigEndian.Uint64(header))),
+		Response:   response,
+	}, nil
+}
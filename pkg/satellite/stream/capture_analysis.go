@@ -0,0 +1,182 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+)
+
+// Formats of capture files.
+const (
+	// Telemetry data as written by --output-file, without frame boundaries or timestamps.
+	CaptureFormatRaw = "raw"
+	// Stream responses as written by CaptureWriter.
+	CaptureFormatStructured = "structured"
+)
+
+// SizeDistribution summarizes the sizes of the frames of a capture in bytes. Percentiles are exact to within 1/64.
+type SizeDistribution struct {
+	Min  int64
+	Max  int64
+	Mean float64
+	P50  int64
+	P90  int64
+	P99  int64
+}
+
+// CaptureAnalysis holds the statistics of a capture file.
+type CaptureAnalysis struct {
+	Path   string
+	Format string
+	// Satellite the capture was recorded for, empty for raw captures.
+	SatelliteID string
+
+	// Telemetry bytes and their CRC32C (Castagnoli) in the order received.
+	TotalBytes int64
+	CRC32C     uint32
+
+	// The fields below are only known for structured captures.
+	Messages   int64
+	Frames     int64
+	FrameSizes *SizeDistribution
+	// Local times the first and last response were received.
+	FirstReceived *time.Time
+	LastReceived  *time.Time
+	// Times the ground station received the first and last byte.
+	FirstByteReceived *time.Time
+	LastByteReceived  *time.Time
+	// Whether the file ends with an incomplete record, e.g. after a crash.
+	Truncated bool
+	// Summary of every plan in the capture, in order.
+	Passes []*PassSummary
+}
+
+// AnalyzeCapture reads a capture file in either format. The pass report of every plan in a structured capture is
+// printed if printReports is set, and pass summary files are written if summary is not nil.
+func AnalyzeCapture(path string, summary *PassSummaryOptions, printReports bool) (*CaptureAnalysis, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	a := &CaptureAnalysis{Path: path}
+	reader, err := NewCaptureReader(file)
+	if errors.Is(err, ErrNotCaptureFile) {
+		a.Format = CaptureFormatRaw
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return a, a.analyzeRaw(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a.Format = CaptureFormatStructured
+	a.SatelliteID = reader.SatelliteID
+	return a, a.analyzeStructured(reader, summary, printReports)
+}
+
+func (a *CaptureAnalysis) analyzeRaw(r io.Reader) error {
+	hash := crc32.New(castagnoliTable)
+	n, err := io.Copy(hash, r)
+	if err != nil {
+		return err
+	}
+	a.TotalBytes = n
+	a.CRC32C = hash.Sum32()
+	return nil
+}
+
+func (a *CaptureAnalysis) analyzeStructured(reader *CaptureReader, summary *PassSummaryOptions, printReports bool) error {
+	// Replay the capture through a collector for the same statistics as a live stream.
+	var logger func(format string, v ...interface{})
+	if printReports {
+		logger = func(format string, v ...interface{}) {}
+	}
+	metrics := NewMetricsCollector(logger)
+	metrics.setSummaryOptions(a.SatelliteID, summary)
+	metrics.setPassHandler(func(s *PassSummary) {
+		a.Passes = append(a.Passes, s)
+	})
+
+	hash := crc32.New(castagnoliTable)
+	// the histogram takes any non-negative values, not only durations
+	sizes := latencyHistogram{}
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err == io.ErrUnexpectedEOF {
+			a.Truncated = true
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		a.Messages++
+		receivedAt := record.ReceivedAt
+		if a.FirstReceived == nil {
+			a.FirstReceived = &receivedAt
+		}
+		a.LastReceived = &receivedAt
+
+		metrics.setStreamId(record.Response.GetStreamId())
+		telemetryResponse := record.Response.GetReceiveTelemetryResponse()
+		if telemetryResponse == nil {
+			continue
+		}
+		metrics.setPlanId(telemetryResponse.GetPlanId())
+		for _, telemetry := range telemetryResponse.GetTelemetry() {
+			if len(telemetry.GetData()) == 0 {
+				continue
+			}
+			size := int64(len(telemetry.Data))
+			a.Frames++
+			a.TotalBytes += size
+			_, _ = hash.Write(telemetry.Data)
+			sizes.record(size)
+			if a.FrameSizes == nil {
+				a.FrameSizes = &SizeDistribution{Min: size}
+			}
+			a.FrameSizes.Min = min(a.FrameSizes.Min, size)
+			if first := toTime(telemetry.TimeFirstByteReceived); first != nil && (a.FirstByteReceived == nil || first.Before(*a.FirstByteReceived)) {
+				a.FirstByteReceived = first
+			}
+			if last := toTime(telemetry.TimeLastByteReceived); last != nil && (a.LastByteReceived == nil || last.After(*a.LastByteReceived)) {
+				a.LastByteReceived = last
+			}
+			metrics.collectTelemetryAt(telemetryResponse.GetGroundStationId(), telemetry, receivedAt)
+		}
+	}
+	metrics.reportPass()
+
+	a.CRC32C = hash.Sum32()
+	if a.FrameSizes != nil {
+		a.FrameSizes.Max = sizes.max
+		a.FrameSizes.Mean = float64(a.TotalBytes) / float64(a.Frames)
+		a.FrameSizes.P50 = sizes.percentile(50)
+		a.FrameSizes.P90 = sizes.percentile(90)
+		a.FrameSizes.P99 = sizes.percentile(99)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
+)
+
+func telemetryResponse(planId string, start time.Time, sizes ...int) *stellarstation.SatelliteStreamResponse {
+	response := &stellarstation.ReceiveTelemetryResponse{PlanId: planId, GroundStationId: "gs_1"}
+	for i, size := range sizes {
+		first := start.Add(time.Duration(i) * time.Second)
+		telemetry := createTelemetry(&first, 100)
+		telemetry.Data = make([]byte, size)
+		telemetry.Data[0] = byte(i)
+		response.Telemetry = append(response.Telemetry, telemetry)
+	}
+	return &stellarstation.SatelliteStreamResponse{
+		StreamId: "stream1",
+		Response: &stellarstation.SatelliteStreamResponse_ReceiveTelemetryResponse{ReceiveTelemetryResponse: response},
+	}
+}
+
+func TestAnalyzeCapture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pass.cap")
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	w, err := CreateCaptureFile(path, "sat1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, w.Write(start.Add(time.Second), telemetryResponse("plan1", start, 10, 20)), nil, "")
+	assertEqual(t, w.Write(start.Add(2*time.Second), &stellarstation.SatelliteStreamResponse{StreamId: "stream1"}), nil, "")
+	assertEqual(t, w.Close(), nil, "")
+
+	// Appending keeps the header.
+	w, err = CreateCaptureFile(path, "sat1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, w.Write(start.Add(time.Hour), telemetryResponse("plan2", start.Add(time.Hour), 30)), nil, "")
+	assertEqual(t, w.Close(), nil, "")
+
+	// An incomplete record left by a crash is ignored.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 1})
+	f.Close()
+
+	a, err := AnalyzeCapture(path, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, a.Format, CaptureFormatStructured, "")
+	assertEqual(t, a.SatelliteID, "sat1", "")
+	assertEqual(t, a.Messages, int64(3), "")
+	assertEqual(t, a.Frames, int64(3), "")
+	assertEqual(t, a.TotalBytes, int64(60), "")
+	assertEqual(t, a.Truncated, true, "")
+	assertEqual(t, a.FrameSizes.Min, int64(10), "")
+	assertEqual(t, a.FrameSizes.Max, int64(30), "")
+	assertEqual(t, a.FrameSizes.P50, int64(20), "")
+	assertEqual(t, a.FirstByteReceived.Equal(start), true, "")
+	assertEqual(t, a.LastReceived.Equal(start.Add(time.Hour)), true, "")
+
+	assertEqual(t, len(a.Passes), 2, "")
+	assertEqual(t, a.Passes[0].SatelliteID, "sat1", "")
+	assertEqual(t, a.Passes[0].PlanID, "plan1", "")
+	assertEqual(t, a.Passes[0].StreamID, "stream1", "")
+	assertEqual(t, a.Passes[0].TotalBytes, int64(30), "")
+	// delay by the recorded receive time: 1s after the first byte, 100ms after the last byte of the first frame
+	assertEqual(t, a.Passes[0].Delay.MaxSeconds > 0.89 && a.Passes[0].Delay.MaxSeconds < 0.91, true, "")
+	assertEqual(t, a.Passes[1].PlanID, "plan2", "")
+	assertEqual(t, a.Passes[1].TotalChunks, int64(1), "")
+
+	// The CRC32C covers the telemetry data in order, as in a raw capture of the same stream.
+	raw := filepath.Join(t.TempDir(), "pass.raw")
+	var data []byte
+	for _, response := range []*stellarstation.SatelliteStreamResponse{telemetryResponse("plan1", start, 10, 20), telemetryResponse("plan2", start, 30)} {
+		for _, telemetry := range response.GetReceiveTelemetryResponse().Telemetry {
+			data = append(data, telemetry.Data...)
+		}
+	}
+	assertEqual(t, os.WriteFile(raw, data, 0644), nil, "")
+	r, err := AnalyzeCapture(raw, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, r.Format, CaptureFormatRaw, "")
+	assertEqual(t, r.TotalBytes, int64(60), "")
+	assertEqual(t, r.CRC32C, crc32.Checksum(data, castagnoliTable), "")
+	assertEqual(t, r.CRC32C, a.CRC32C, "")
+}
@@ -0,0 +1,90 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/infostellarinc/stellarcli/pkg/logger"
+	"github.com/infostellarinc/stellarcli/pkg/ntp"
+)
+
+// ClockOffsetInterval - Interval between measurements of the local clock offset
+const ClockOffsetInterval = 5 * time.Minute
+
+// clockOffsetTimeout - Maximum time to wait for an NTP server to answer
+const clockOffsetTimeout = 5 * time.Second
+
+// clockOffsetTracker periodically measures the offset of the local clock with an NTP server and corrects the
+// telemetry delay of a collector for it.
+type clockOffsetTracker struct {
+	server   string
+	metrics  *MetricsCollector
+	stopChan chan struct{}
+	done     sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// start measuring the clock offset with server, first right away and then every ClockOffsetInterval
+func startClockOffsetTracker(server string, metrics *MetricsCollector) *clockOffsetTracker {
+	t := &clockOffsetTracker{
+		server:   server,
+		metrics:  metrics,
+		stopChan: make(chan struct{}),
+	}
+	t.done.Add(1)
+	go t.run()
+	return t
+}
+
+func (t *clockOffsetTracker) run() {
+	defer t.done.Done()
+
+	t.measure()
+	ticker := time.NewTicker(ClockOffsetInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.measure()
+		case <-t.stopChan:
+			return
+		}
+	}
+}
+
+// query the NTP server; on failure the last known offset is kept
+func (t *clockOffsetTracker) measure() {
+	result, err := ntp.Query(t.server, clockOffsetTimeout)
+	if err != nil {
+		log.Printf("could not measure clock offset with %s: %v\n", t.server, err)
+		return
+	}
+	t.metrics.setClockOffset(result.Offset, t.server)
+	log.Verbose("local clock offset %v (round trip %v, stratum %d) measured with %s\n",
+		result.Offset, result.RTT, result.Stratum, t.server)
+}
+
+// stop measuring and wait for a query in progress to finish. Safe to call more than once and on a nil tracker.
+func (t *clockOffsetTracker) stop() {
+	if t == nil {
+		return
+	}
+	t.stopOnce.Do(func() {
+		close(t.stopChan)
+	})
+	t.done.Wait()
+}
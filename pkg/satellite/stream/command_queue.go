@@ -0,0 +1,167 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/infostellarinc/stellarcli/pkg/logger"
+)
+
+// ErrCommandQueueFull is returned when a command is sent while the command queue is full.
+var ErrCommandQueueFull = errors.New("command queue is full")
+
+type CommandQueueOptions struct {
+	// Maximum number of commands sent per second. Zero disables the limit.
+	CommandsPerSecond float64
+	// Maximum uplink rate in bits per second. Zero disables the limit.
+	BitsPerSecond uint64
+	// Maximum number of commands waiting to be sent.
+	MaxDepth int
+}
+
+type queuedCommand struct {
+	source   string
+	payload  []byte
+	queuedAt time.Time
+}
+
+// commandQueue paces commands sent to the satellite so that the uplink is never overrun.
+type commandQueue struct {
+	commandsPerSecond float64
+	bitsPerSecond     uint64
+
+	commands  chan *queuedCommand
+	send      func(source string, payload []byte) error
+	dropped   func(source string, payload []byte)
+	closeChan chan struct{}
+	closeOnce sync.Once
+	closeWg   sync.WaitGroup
+}
+
+// newCommandQueue starts a queue that passes commands to send at the configured rate. dropped is called for every
+// command that is never sent.
+func newCommandQueue(o *CommandQueueOptions, send func(source string, payload []byte) error,
+	dropped func(source string, payload []byte)) *commandQueue {
+	q := &commandQueue{
+		commandsPerSecond: o.CommandsPerSecond,
+		bitsPerSecond:     o.BitsPerSecond,
+		commands:          make(chan *queuedCommand, o.MaxDepth),
+		send:              send,
+		dropped:           dropped,
+		closeChan:         make(chan struct{}),
+	}
+
+	q.closeWg.Add(1)
+	go q.sendLoop()
+
+	return q
+}
+
+// enqueue a command. The payload is copied, so the caller may reuse it.
+func (q *commandQueue) enqueue(source string, payload []byte) error {
+	c := &queuedCommand{
+		source:   source,
+		payload:  append([]byte(nil), payload...),
+		queuedAt: time.Now(),
+	}
+
+	select {
+	case q.commands <- c:
+		log.Debug("queued command: size: %d bytes, queue depth: %d\n", len(payload), len(q.commands))
+		return nil
+	default:
+		log.Printf("dropped command: size: %d bytes, queue is full (%d commands)\n", len(payload), cap(q.commands))
+		q.dropped(source, payload)
+		return ErrCommandQueueFull
+	}
+}
+
+// interval returns the time the uplink needs for a command of the given size.
+func (q *commandQueue) interval(size int) time.Duration {
+	var interval time.Duration
+	if q.commandsPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / q.commandsPerSecond)
+	}
+	if q.bitsPerSecond > 0 {
+		if d := time.Duration(float64(size*8) / float64(q.bitsPerSecond) * float64(time.Second)); d > interval {
+			interval = d
+		}
+	}
+	return interval
+}
+
+func (q *commandQueue) sendLoop() {
+	defer q.closeWg.Done()
+
+	nextSendAt := time.Now()
+	for {
+		select {
+		case c := <-q.commands:
+			if wait := time.Until(nextSendAt); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-q.closeChan:
+					q.drop(c)
+					return
+				}
+			}
+
+			sentAt := time.Now()
+			if err := q.send(c.source, c.payload); err != nil {
+				log.Printf("failed to send command at %s: size: %d bytes, queued for: %v, error: %v\n",
+					sentAt.UTC().Format(time.RFC3339Nano), len(c.payload), sentAt.Sub(c.queuedAt), err)
+			} else {
+				log.Printf("sent command at %s: size: %d bytes, queued for: %v\n",
+					sentAt.UTC().Format(time.RFC3339Nano), len(c.payload), sentAt.Sub(c.queuedAt))
+			}
+			nextSendAt = sentAt.Add(q.interval(len(c.payload)))
+		case <-q.closeChan:
+			q.drop(nil)
+			return
+		}
+	}
+}
+
+// drop the commands left unsent when closing, including inFlight if it is not nil.
+func (q *commandQueue) drop(inFlight *queuedCommand) {
+	n := 0
+	if inFlight != nil {
+		q.dropped(inFlight.source, inFlight.payload)
+		n++
+	}
+	for {
+		select {
+		case c := <-q.commands:
+			q.dropped(c.source, c.payload)
+			n++
+		default:
+			if n > 0 {
+				log.Printf("dropped %d queued commands on close.\n", n)
+			}
+			return
+		}
+	}
+}
+
+// close stops sending. Commands still queued are dropped. Closing an already closed queue does nothing.
+func (q *commandQueue) close() {
+	q.closeOnce.Do(func() {
+		close(q.closeChan)
+	})
+	q.closeWg.Wait()
+}
@@ -0,0 +1,91 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCommandQueueInterval(t *testing.T) {
+	q := &commandQueue{commandsPerSecond: 10}
+	assertEqual(t, q.interval(100), 100*time.Millisecond, "")
+
+	// The bitrate limit applies when it is slower than the command rate.
+	q.bitsPerSecond = 1000
+	assertEqual(t, q.interval(100), 800*time.Millisecond, "")
+	assertEqual(t, q.interval(1), 100*time.Millisecond, "")
+}
+
+func TestCommandQueuePacing(t *testing.T) {
+	var mu sync.Mutex
+	var sentAt []time.Time
+	q := newCommandQueue(&CommandQueueOptions{CommandsPerSecond: 20, MaxDepth: 10}, func(source string, payload []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sentAt = append(sentAt, time.Now())
+		return nil
+	}, func(source string, payload []byte) {})
+
+	payload := []byte("command")
+	for i := 0; i < 3; i++ {
+		if err := q.enqueue("test", payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(150 * time.Millisecond)
+	q.close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assertEqual(t, len(sentAt), 3, "")
+	for i := 1; i < len(sentAt); i++ {
+		if gap := sentAt[i].Sub(sentAt[i-1]); gap < 45*time.Millisecond {
+			t.Fatalf("commands sent %v apart, expected at least 50ms", gap)
+		}
+	}
+}
+
+func TestCommandQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	var dropped []string
+	q := newCommandQueue(&CommandQueueOptions{MaxDepth: 1}, func(source string, payload []byte) error {
+		<-block
+		return nil
+	}, func(source string, payload []byte) {
+		dropped = append(dropped, string(payload))
+	})
+
+	// The first command is taken by the send loop, the second fills the queue.
+	_ = q.enqueue("test", []byte("1"))
+	time.Sleep(10 * time.Millisecond)
+	assertEqual(t, q.enqueue("test", []byte("2")), nil, "")
+	assertEqual(t, q.enqueue("test", []byte("3")), ErrCommandQueueFull, "")
+	assertEqual(t, len(dropped), 1, "")
+	assertEqual(t, dropped[0], "3", "")
+
+	close(block)
+	q.close()
+}
+
+func TestCommandQueueCloseTwice(t *testing.T) {
+	q := newCommandQueue(&CommandQueueOptions{MaxDepth: 1}, func(source string, payload []byte) error {
+		return nil
+	}, func(source string, payload []byte) {})
+
+	q.close()
+	q.close()
+}
@@ -14,14 +14,20 @@
 
 package stream
 
+import (
+	log "github.com/infostellarinc/stellarcli/pkg/logger"
+)
+
 type noProxy struct {
 	stream     SatelliteStream
-	streamChan chan []byte
+	streamChan chan *Frame
+	spool      *Spool
+	counters   *StreamCounters
 }
 
 // Create a connection without using a proxy.
 func NewConnectionWithoutProxy() (Proxy, error) {
-	streamChan := make(chan []byte)
+	streamChan := make(chan *Frame)
 
 	p := &noProxy{
 		streamChan: streamChan,
@@ -35,6 +41,8 @@ func (p *noProxy) Start(o *SatelliteStreamOptions) (func(), error) {
 
 	var err error
 	var cleanup func()
+	p.spool = o.Spool
+	p.counters = o.streamCounters()
 	p.stream, cleanup, err = OpenSatelliteStream(o, p.streamChan)
 	if err != nil {
 		return cleanup, err
@@ -45,12 +53,18 @@ func (p *noProxy) Start(o *SatelliteStreamOptions) (func(), error) {
 	return cleanup, nil
 }
 
+// Discards received packets, or keeps them in the spool for a later consumer when one is configured.
 func (p *noProxy) serve() {
-	//nolint:gosimple
-	for {
-		select {
-		case <-p.streamChan:
+	for frame := range p.streamChan {
+		if p.spool != nil {
+			if err := p.spool.Write(frame.Data); err != nil {
+				log.PrintlnThrottled("could not spool frame: %v", err)
+				p.counters.addDroppedFrame()
+				frame.Fail()
+				continue
+			}
 		}
+		frame.Done()
 	}
 }
 
@@ -0,0 +1,302 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
+
+	log "github.com/infostellarinc/stellarcli/pkg/logger"
+)
+
+// MonitoringEvent is the ground station state reported by a PlanMonitoringEvent, flattened for consumers.
+type MonitoringEvent struct {
+	Time        time.Time         `json:"time"`
+	SatelliteID string            `json:"satellite_id"`
+	StreamID    string            `json:"stream_id"`
+	PlanID      string            `json:"plan_id"`
+	Antenna     *AntennaEvent     `json:"antenna,omitempty"`
+	Receiver    *ReceiverEvent    `json:"receiver,omitempty"`
+	Transmitter *TransmitterEvent `json:"transmitter,omitempty"`
+}
+
+// AntennaEvent holds the commanded and measured antenna angles in degrees.
+type AntennaEvent struct {
+	AzimuthCommand    float64 `json:"azimuth_command"`
+	AzimuthMeasured   float64 `json:"azimuth_measured"`
+	ElevationCommand  float64 `json:"elevation_command"`
+	ElevationMeasured float64 `json:"elevation_measured"`
+	Polarization      string  `json:"polarization"`
+}
+
+// ReceiverEvent holds the receiver lock state and signal quality.
+type ReceiverEvent struct {
+	CenterFrequencyHz       uint64   `json:"center_frequency_hz"`
+	CarrierLevelDbm         float64  `json:"carrier_level_dbm"`
+	PhaseLocked             bool     `json:"phase_locked"`
+	BitSynchronizerLocked   bool     `json:"bit_synchronizer_locked"`
+	FrameSynchronizerLocked bool     `json:"frame_synchronizer_locked"`
+	NormalizedSnr           float64  `json:"normalized_snr"`
+	Bitrate                 *float32 `json:"bitrate,omitempty"`
+}
+
+// TransmitterEvent holds the transmitter configuration state.
+type TransmitterEvent struct {
+	CenterFrequencyHz  uint64   `json:"center_frequency_hz"`
+	CarrierLevelDbm    float64  `json:"carrier_level_dbm"`
+	ModulationEnabled  bool     `json:"modulation_enabled"`
+	CarrierEnabled     bool     `json:"carrier_enabled"`
+	IfSweepEnabled     bool     `json:"if_sweep_enabled"`
+	IdlePatternEnabled bool     `json:"idle_pattern_enabled"`
+	Bitrate            *float32 `json:"bitrate,omitempty"`
+}
+
+// EventSink receives monitoring events from a satellite stream.
+type EventSink interface {
+	// Send delivers an event to the sink.
+	Send(e *MonitoringEvent) error
+
+	io.Closer
+}
+
+// EventSinkFunc adapts a function to the EventSink interface, for use when embedding the stream in other programs.
+type EventSinkFunc func(e *MonitoringEvent) error
+
+// Send calls f(e).
+func (f EventSinkFunc) Send(e *MonitoringEvent) error {
+	return f(e)
+}
+
+// Close does nothing.
+func (f EventSinkFunc) Close() error {
+	return nil
+}
+
+// newMonitoringEvent converts a PlanMonitoringEvent to a MonitoringEvent. It returns nil when the event does not
+// carry ground station state.
+func newMonitoringEvent(satelliteId, streamId string, pme *stellarstation.PlanMonitoringEvent) *MonitoringEvent {
+	gsState := pme.GetGroundStationState()
+	if gsState == nil {
+		return nil
+	}
+
+	e := &MonitoringEvent{
+		Time:        time.Now().UTC(),
+		SatelliteID: satelliteId,
+		StreamID:    streamId,
+		PlanID:      pme.GetPlanId(),
+	}
+
+	if antenna := gsState.GetAntenna(); antenna != nil {
+		e.Antenna = &AntennaEvent{
+			AzimuthCommand:    antenna.GetAzimuth().GetCommand(),
+			AzimuthMeasured:   antenna.GetAzimuth().GetMeasured(),
+			ElevationCommand:  antenna.GetElevation().GetCommand(),
+			ElevationMeasured: antenna.GetElevation().GetMeasured(),
+			Polarization:      antenna.GetPolarization().String(),
+		}
+	}
+
+	if receiver := gsState.GetReceiver(); receiver != nil {
+		e.Receiver = &ReceiverEvent{
+			CenterFrequencyHz:       receiver.GetCenterFrequencyHz(),
+			CarrierLevelDbm:         receiver.GetCarrierLevelDbm(),
+			PhaseLocked:             receiver.GetIsPhaseLocked(),
+			BitSynchronizerLocked:   receiver.GetIsBitSynchronizerLocked(),
+			FrameSynchronizerLocked: receiver.GetIsFrameSynchronizerLocked(),
+			NormalizedSnr:           receiver.GetNormalizedSnr(),
+		}
+		if bitrate := receiver.GetBitrate(); bitrate != nil {
+			v := bitrate.GetValue()
+			e.Receiver.Bitrate = &v
+		}
+	}
+
+	if transmitter := gsState.GetTransmitter(); transmitter != nil {
+		e.Transmitter = &TransmitterEvent{
+			CenterFrequencyHz:  transmitter.GetCenterFrequencyHz(),
+			CarrierLevelDbm:    transmitter.GetCarrierLevelDbm(),
+			ModulationEnabled:  transmitter.GetIsModulationEnabled().GetValue(),
+			CarrierEnabled:     transmitter.GetIsCarrierEnabled().GetValue(),
+			IfSweepEnabled:     transmitter.GetIsIfSweepEnabled().GetValue(),
+			IdlePatternEnabled: transmitter.GetIsIdlePatternEnabled().GetValue(),
+		}
+		if bitrate := transmitter.GetBitrate(); bitrate != nil {
+			v := bitrate.GetValue()
+			e.Transmitter.Bitrate = &v
+		}
+	}
+
+	return e
+}
+
+type jsonLinesEventSink struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+// NewJSONLinesEventSink creates a sink writing one JSON document per line to w.
+func NewJSONLinesEventSink(w io.WriteCloser) EventSink {
+	return &jsonLinesEventSink{w: w}
+}
+
+// NewFileEventSink creates a sink appending events as JSON Lines to the file at path.
+func NewFileEventSink(path string) (EventSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open event file: %w", err)
+	}
+	return NewJSONLinesEventSink(f), nil
+}
+
+func (s *jsonLinesEventSink) Send(e *MonitoringEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
+
+func (s *jsonLinesEventSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Close()
+}
+
+type udpEventSink struct {
+	conn net.Conn
+}
+
+// NewUDPEventSink creates a sink sending each event as a JSON datagram to addr.
+func NewUDPEventSink(addr string) (EventSink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &udpEventSink{conn: conn}, nil
+}
+
+func (s *udpEventSink) Send(e *MonitoringEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = s.conn.Write(b)
+	return err
+}
+
+func (s *udpEventSink) Close() error {
+	return s.conn.Close()
+}
+
+type tcpEventSink struct {
+	listener net.Listener
+
+	mu     sync.Mutex
+	conns  map[net.Conn]bool
+	closed bool
+}
+
+// NewTCPEventSink creates a sink listening on addr and writing events as JSON Lines to every connected client.
+func NewTCPEventSink(addr string) (EventSink, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &tcpEventSink{
+		listener: listener,
+		conns:    make(map[net.Conn]bool),
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			log.Println("connected to a new event client:", conn.RemoteAddr().String())
+
+			s.mu.Lock()
+			if s.closed {
+				// Accepted while the sink was closing.
+				conn.Close()
+			} else {
+				s.conns[conn] = true
+			}
+			s.mu.Unlock()
+		}
+	}()
+
+	return s, nil
+}
+
+func (s *tcpEventSink) Send(e *MonitoringEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		_ = conn.SetWriteDeadline(time.Now().Add(500 * time.Millisecond))
+		if _, err := conn.Write(b); err != nil {
+			delete(s.conns, conn)
+			conn.Close()
+			log.Println("disconnected the event client:", conn.RemoteAddr().String())
+		}
+	}
+	return nil
+}
+
+func (s *tcpEventSink) Close() error {
+	err := s.listener.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for conn := range s.conns {
+		delete(s.conns, conn)
+		conn.Close()
+	}
+
+	return err
+}
+
+// NewEventSinkFromURL creates a network sink from a URL of the form udp://host:port or tcp://host:port.
+// UDP sinks send datagrams to the address, TCP sinks listen on it.
+func NewEventSinkFromURL(url string) (EventSink, error) {
+	switch {
+	case strings.HasPrefix(url, "udp://"):
+		return NewUDPEventSink(strings.TrimPrefix(url, "udp://"))
+	case strings.HasPrefix(url, "tcp://"):
+		return NewTCPEventSink(strings.TrimPrefix(url, "tcp://"))
+	}
+	return nil, fmt.Errorf("unsupported event address: %v. Expected udp://host:port or tcp://host:port", url)
+}
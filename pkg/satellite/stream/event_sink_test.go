@@ -0,0 +1,123 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
+	"github.com/infostellarinc/go-stellarstation/api/v1/monitoring"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func createMonitoringEvent() *stellarstation.PlanMonitoringEvent {
+	return &stellarstation.PlanMonitoringEvent{
+		PlanId: "plan_1",
+		Info: &stellarstation.PlanMonitoringEvent_GroundStationState{
+			GroundStationState: &monitoring.GroundStationState{
+				Antenna: &monitoring.AntennaState{
+					Azimuth:   &monitoring.AntennaState_Angle{Command: 120, Measured: 120.5},
+					Elevation: &monitoring.AntennaState_Angle{Command: 30, Measured: 29.5},
+				},
+				Receiver: &monitoring.ReceiverState{
+					CenterFrequencyHz: 437_000_000,
+					IsPhaseLocked:     true,
+					NormalizedSnr:     12.5,
+				},
+				Transmitter: &monitoring.TransmitterState{
+					IsCarrierEnabled: wrapperspb.Bool(true),
+				},
+			},
+		},
+	}
+}
+
+func TestNewMonitoringEvent(t *testing.T) {
+	e := newMonitoringEvent("sat_1", "stream_1", createMonitoringEvent())
+	assertEqual(t, e.PlanID, "plan_1", "")
+	assertEqual(t, e.StreamID, "stream_1", "")
+	assertEqual(t, e.Antenna.AzimuthMeasured, 120.5, "")
+	assertEqual(t, e.Antenna.ElevationMeasured, 29.5, "")
+	assertEqual(t, e.Receiver.PhaseLocked, true, "")
+	assertEqual(t, e.Receiver.NormalizedSnr, 12.5, "")
+	assertEqual(t, e.Transmitter.CarrierEnabled, true, "")
+	assertEqual(t, e.Transmitter.ModulationEnabled, false, "")
+
+	noState := newMonitoringEvent("sat_1", "stream_1", &stellarstation.PlanMonitoringEvent{PlanId: "plan_1"})
+	if noState != nil {
+		t.Fatal("expected no event without ground station state")
+	}
+}
+
+func TestFileEventSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink, err := NewFileEventSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := sink.Send(newMonitoringEvent("sat_1", "stream_1", createMonitoringEvent())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e MonitoringEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, e.SatelliteID, "sat_1", "")
+		lines++
+	}
+	assertEqual(t, lines, 3, "")
+}
+
+func TestTCPEventSinkCloseWhileConnecting(t *testing.T) {
+	sink, err := NewTCPEventSink("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := sink.(*tcpEventSink).listener.Addr().String()
+
+	for i := 0; i < 10; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Send(newMonitoringEvent("sat_1", "stream_1", createMonitoringEvent())); err != nil {
+		t.Fatal(err)
+	}
+}
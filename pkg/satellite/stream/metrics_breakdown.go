@@ -0,0 +1,130 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PassBreakdown holds the statistics of the telemetry of a plan received from one ground station or with one
+// framing.
+type PassBreakdown struct {
+	Name                string  `json:"name" yaml:"name"`
+	TotalBytes          int64   `json:"total_bytes" yaml:"total_bytes"`
+	TotalChunks         int64   `json:"total_chunks" yaml:"total_chunks"`
+	AverageRateBps      int64   `json:"average_rate_bps" yaml:"average_rate_bps"`
+	AverageDelaySeconds float64 `json:"average_delay_seconds" yaml:"average_delay_seconds"`
+}
+
+// telemetry totals of one ground station or framing
+type breakdownStats struct {
+	totalBytes    int64
+	totalChunks   int64
+	delayNanos    int64
+	firstReceived time.Time
+	lastReceived  time.Time
+}
+
+func (b *breakdownStats) collect(size int, delayNanos int64, receivedTime time.Time) {
+	if b.totalChunks == 0 {
+		b.firstReceived = receivedTime
+	}
+	b.totalBytes += int64(size)
+	b.totalChunks++
+	b.delayNanos += delayNanos
+	b.lastReceived = receivedTime
+}
+
+func (b *breakdownStats) avgRate() int64 {
+	elapsed := b.lastReceived.Sub(b.firstReceived).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return int64(float64(b.totalBytes) / elapsed * 8.00)
+}
+
+func (b *breakdownStats) avgDelay() int64 {
+	if b.totalChunks == 0 {
+		return 0
+	}
+	return b.delayNanos / b.totalChunks
+}
+
+// metricsBreakdown splits telemetry statistics by a key such as the ground station ID or the framing.
+type metricsBreakdown map[string]*breakdownStats
+
+func (m metricsBreakdown) collect(key string, size int, delayNanos int64, receivedTime time.Time) {
+	b, ok := m[key]
+	if !ok {
+		b = &breakdownStats{}
+		m[key] = b
+	}
+	b.collect(size, delayNanos, receivedTime)
+}
+
+func (m metricsBreakdown) sortedKeys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (m metricsBreakdown) summary() []PassBreakdown {
+	summary := make([]PassBreakdown, 0, len(m))
+	for _, key := range m.sortedKeys() {
+		b := m[key]
+		summary = append(summary, PassBreakdown{
+			Name:                key,
+			TotalBytes:          b.totalBytes,
+			TotalChunks:         b.totalChunks,
+			AverageRateBps:      b.avgRate(),
+			AverageDelaySeconds: float64(b.avgDelay()) / 1e9,
+		})
+	}
+	return summary
+}
+
+// status returns a short summary for the live stats line, or an empty string unless the telemetry is split over
+// more than one key
+func (m metricsBreakdown) status(label string) string {
+	if len(m) < 2 {
+		return ""
+	}
+	parts := make([]string, 0, len(m))
+	for _, key := range m.sortedKeys() {
+		b := m[key]
+		parts = append(parts, fmt.Sprintf("%s: %s %sbps", key, humanReadableBytes(b.totalBytes), humanReadableCountSI(b.avgRate())))
+	}
+	return fmt.Sprintf(", %s: [%s]", label, strings.Join(parts, ", "))
+}
+
+// print the breakdown for the pass report
+func (m metricsBreakdown) logReport(title string, logger func(format string, a ...interface{}) (int, error)) {
+	if len(m) == 0 {
+		return
+	}
+	_, _ = logger("  %s\n", title)
+	for _, key := range m.sortedKeys() {
+		b := m[key]
+		_, _ = logger("    %-20s : %d bytes (%s), %d chunks, %sbps, delay %s\n", key, b.totalBytes, humanReadableBytes(b.totalBytes),
+			b.totalChunks, humanReadableCountSI(b.avgRate()), humanReadableNanoSeconds(b.avgDelay()))
+	}
+	_, _ = logger("\n")
+}
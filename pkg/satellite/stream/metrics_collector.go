@@ -16,11 +16,13 @@ package stream
 
 import (
 	"fmt"
+	"hash/crc32"
 	"sync"
 	"time"
 
 	"github.com/golang/protobuf/ptypes/timestamp"
 	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
+	log "github.com/infostellarinc/stellarcli/pkg/logger"
 )
 
 // InstantMinSamples - Minimum number of samples to calculate instantaneous stats with (rate & delay)
@@ -32,14 +34,84 @@ const InstantMaxSamples = 50000
 // InstantSampleSeconds - Duration of data samples to calculate instantaneous stats with
 const InstantSampleSeconds = 10
 
+// DefaultGapThreshold - Default minimum time without telemetry during a plan counted as a reception gap
+const DefaultGapThreshold = 10 * time.Second
+
+// maxTrackedChunks - Maximum number of recent chunks remembered to detect duplicates
+const maxTrackedChunks = 100000
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// identifies a telemetry chunk to detect duplicates
+type chunkKey struct {
+	firstByteNanos int64
+	lastByteNanos  int64
+	size           int
+	crc            uint32
+}
+
 type telemetryWithTimestamp struct {
-	ReceivedTime         time.Time
-	DataBytes            int
-	TimeLastByteReceived *timestamp.Timestamp
+	ReceivedTime time.Time
+	DataBytes    int
+	// delay corrected for the clock offset known when the message was received
+	DelayNanos int64
+}
+
+// sampleRing holds the recent messages instantaneous stats are calculated with, in order of arrival, and keeps
+// running totals so that the stats take constant time. It grows up to InstantMaxSamples and is then reused.
+type sampleRing struct {
+	buf        []telemetryWithTimestamp
+	head       int
+	size       int
+	bytes      int64
+	delayNanos int64
+}
+
+func (r *sampleRing) len() int {
+	return r.size
+}
+
+func (r *sampleRing) at(i int) *telemetryWithTimestamp {
+	return &r.buf[(r.head+i)%len(r.buf)]
+}
+
+func (r *sampleRing) push(msg telemetryWithTimestamp) {
+	if r.size == len(r.buf) {
+		grown := make([]telemetryWithTimestamp, max(64, 2*len(r.buf)))
+		for i := 0; i < r.size; i++ {
+			grown[i] = *r.at(i)
+		}
+		r.buf = grown
+		r.head = 0
+	}
+	*r.at(r.size) = msg
+	r.size++
+	r.bytes += int64(msg.DataBytes)
+	r.delayNanos += msg.DelayNanos
+}
+
+// remove the oldest message
+func (r *sampleRing) pop() {
+	oldest := r.at(0)
+	r.bytes -= int64(oldest.DataBytes)
+	r.delayNanos -= oldest.DelayNanos
+	r.head = (r.head + 1) % len(r.buf)
+	r.size--
 }
 
-// MetricsCollector holds metrics used to display pass report and instantaneous stats
+func (r *sampleRing) clear() {
+	r.head = 0
+	r.size = 0
+	r.bytes = 0
+	r.delayNanos = 0
+}
+
+// MetricsCollector holds metrics used to display pass report and instantaneous stats.
+// It is safe for concurrent use; each stream owns its own collector.
 type MetricsCollector struct {
+	// mu guards all fields below
+	mu sync.Mutex
+
 	planId                string
 	streamId              string
 	timerStart            time.Time
@@ -50,39 +122,111 @@ type MetricsCollector struct {
 	elevation             float64
 	frequency             float64
 	delayNanos            int64
-	statsLoggingScheduler bool
-	writeLock             sync.Mutex
 
-	messageBuffer                 []telemetryWithTimestamp
+	// Totals of the plans received before the current one, by plan ID, so that they keep counting up if a plan is
+	// received again.
+	earlierPlans map[string]planTotals
+
+	messageBuffer                 sampleRing
 	starpassTimeFirstByteReceived *timestamp.Timestamp
 	starpassTimeLastByteReceived  *timestamp.Timestamp
 	localTimeFirstByteReceived    *timestamp.Timestamp
 	localTimeLastByteReceived     *timestamp.Timestamp
 	logger                        func(format string, v ...interface{})
+
+	satelliteId       string
+	summaryOptions    *PassSummaryOptions
+	reconnects        int64
+	gapThreshold      time.Duration
+	gaps              []Gap
+	lastReceivedTime  time.Time
+	lastByteTime      time.Time
+	duplicates        int64
+	seenChunks        map[chunkKey]struct{}
+	seenChunksInOrder []chunkKey
+	byGroundStation   metricsBreakdown
+	byFraming         metricsBreakdown
+	// distributions of the delay of each chunk and of the time between consecutive chunks
+	delayHistogram        latencyHistogram
+	interArrivalHistogram latencyHistogram
+
+	// estimated offset to add to the local clock to get the true time, and where it was measured
+	clockOffset       time.Duration
+	clockOffsetSource string
+
+	// called with the summary of every plan reported, if set
+	onPass func(*PassSummary)
+
+	// closed to stop the stats emit scheduler, nil when it is not running
+	stopChan    chan struct{}
+	stoppedChan chan struct{}
 }
 
-// NewMetricsCollector creates a stats collector
+// NewMetricsCollector creates a stats collector. With a nil logger, statistics are collected but never printed.
 func NewMetricsCollector(logger func(format string, v ...interface{})) *MetricsCollector {
-	logger("[STATS] using local time to calculate telemetry delay")
 	return &MetricsCollector{
-		logger:                logger,
-		statsLoggingScheduler: false,
+		logger:       logger,
+		gapThreshold: DefaultGapThreshold,
 	}
 }
 
+// correct telemetry delay by offset, the estimated error of the local clock measured with source
+func (metrics *MetricsCollector) setClockOffset(offset time.Duration, source string) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.clockOffset = offset
+	metrics.clockOffsetSource = source
+}
+
+// call fn with the summary of every plan reported
+func (metrics *MetricsCollector) setPassHandler(fn func(*PassSummary)) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.onPass = fn
+}
+
+// count intervals longer than threshold without telemetry as reception gaps
+func (metrics *MetricsCollector) setGapThreshold(threshold time.Duration) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.gapThreshold = threshold
+}
+
+// write a pass summary file for every plan of satelliteId
+func (metrics *MetricsCollector) setSummaryOptions(satelliteId string, o *PassSummaryOptions) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.satelliteId = satelliteId
+	metrics.summaryOptions = o
+}
+
 // planId is used to identify when to reset the statistics; upon reset, stats is printed to output
 func (metrics *MetricsCollector) setPlanId(planId string) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
 	if metrics.planId != planId {
-		metrics.logReport()
+		metrics.report()
+		if metrics.totalMessagesReceived > 0 {
+			if metrics.earlierPlans == nil {
+				metrics.earlierPlans = make(map[string]planTotals)
+			}
+			totals := metrics.earlierPlans[metrics.planId]
+			totals.bytes += metrics.totalBytesReceived
+			totals.messages += metrics.totalMessagesReceived
+			metrics.earlierPlans[metrics.planId] = totals
+		}
 		metrics.planId = planId
 		metrics.reset()
 	}
 }
 
 func (metrics *MetricsCollector) setStreamId(streamId string) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
 	metrics.streamId = streamId
 }
 
+// must be called with mu held
 func (metrics *MetricsCollector) reset() {
 	metrics.timerStart = time.Now()
 	metrics.totalBytesReceived = 0
@@ -91,58 +235,140 @@ func (metrics *MetricsCollector) reset() {
 	metrics.elevation = 0
 	metrics.frequency = 0
 	metrics.delayNanos = 0
-	metrics.messageBuffer = make([]telemetryWithTimestamp, 0)
+	metrics.messageBuffer.clear()
 	metrics.starpassTimeFirstByteReceived = nil
 	metrics.starpassTimeLastByteReceived = nil
 	metrics.localTimeFirstByteReceived = nil
 	metrics.localTimeLastByteReceived = nil
+	metrics.reconnects = 0
+	metrics.gaps = nil
+	metrics.lastReceivedTime = time.Time{}
+	metrics.lastByteTime = time.Time{}
+	metrics.duplicates = 0
+	metrics.seenChunks = nil
+	metrics.seenChunksInOrder = nil
+	metrics.byGroundStation = metricsBreakdown{}
+	metrics.byFraming = metricsBreakdown{}
+	metrics.delayHistogram = latencyHistogram{}
+	metrics.interArrivalHistogram = latencyHistogram{}
 }
 
-// collects metrics for telemetry data message
-func (metrics *MetricsCollector) collectTelemetry(telemetry *stellarstation.Telemetry) {
+// record a reconnection to the API stream
+func (metrics *MetricsCollector) collectReconnect() {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.reconnects++
+}
+
+// count reception gaps and duplicate chunks; must be called with mu held
+func (metrics *MetricsCollector) collectContinuity(telemetry *stellarstation.Telemetry, receivedTime time.Time) {
+	// gaps by the time the CLI received the telemetry
+	if !metrics.lastReceivedTime.IsZero() && receivedTime.Sub(metrics.lastReceivedTime) > metrics.gapThreshold {
+		metrics.gaps = append(metrics.gaps, newGap(GapClockLocal, metrics.lastReceivedTime, receivedTime))
+	}
+	metrics.lastReceivedTime = receivedTime
+
+	// gaps by the time the ground station received the telemetry; chunks arriving out of order never open a gap
+	firstByteTime := *toTime(telemetry.TimeFirstByteReceived)
+	lastByteTime := *toTime(telemetry.TimeLastByteReceived)
+	if !metrics.lastByteTime.IsZero() && firstByteTime.Sub(metrics.lastByteTime) > metrics.gapThreshold {
+		metrics.gaps = append(metrics.gaps, newGap(GapClockGroundStation, metrics.lastByteTime, firstByteTime))
+	}
+	if lastByteTime.After(metrics.lastByteTime) {
+		metrics.lastByteTime = lastByteTime
+	}
+
+	key := chunkKey{
+		firstByteNanos: toTime(telemetry.TimeFirstByteReceived).UnixNano(),
+		lastByteNanos:  toTime(telemetry.TimeLastByteReceived).UnixNano(),
+		size:           len(telemetry.Data),
+		crc:            crc32.Checksum(telemetry.Data, castagnoliTable),
+	}
+	if metrics.seenChunks == nil {
+		metrics.seenChunks = make(map[chunkKey]struct{})
+	}
+	if _, ok := metrics.seenChunks[key]; ok {
+		metrics.duplicates++
+		return
+	}
+	metrics.seenChunks[key] = struct{}{}
+	metrics.seenChunksInOrder = append(metrics.seenChunksInOrder, key)
+	if len(metrics.seenChunksInOrder) > maxTrackedChunks {
+		delete(metrics.seenChunks, metrics.seenChunksInOrder[0])
+		metrics.seenChunksInOrder = metrics.seenChunksInOrder[1:]
+	}
+}
+
+// collects metrics for telemetry data message received from the given ground station
+func (metrics *MetricsCollector) collectTelemetry(groundStationId string, telemetry *stellarstation.Telemetry) {
+	metrics.collectTelemetryAt(groundStationId, telemetry, time.Now())
+}
+
+// collects metrics for telemetry data message received at receivedTime, e.g. when replaying a capture file
+func (metrics *MetricsCollector) collectTelemetryAt(groundStationId string, telemetry *stellarstation.Telemetry, receivedTime time.Time) {
 	if telemetry != nil && telemetry.TimeFirstByteReceived != nil && telemetry.TimeLastByteReceived != nil && telemetry.Data != nil && len(telemetry.Data) > 0 {
-		// sum of delay of all data messages
-		metrics.delayNanos += time.Now().UTC().UnixNano() - ((telemetry.TimeLastByteReceived.Seconds * 1e9) + int64(telemetry.TimeLastByteReceived.Nanos))
-		metrics.collectMessage(len(telemetry.Data))
+		metrics.mu.Lock()
+		defer metrics.mu.Unlock()
+
+		// sum of delay of all data messages, measured by the local clock corrected for its estimated offset
+		delayNanos := receivedTime.Add(metrics.clockOffset).UnixNano() - ((telemetry.TimeLastByteReceived.Seconds * 1e9) + int64(telemetry.TimeLastByteReceived.Nanos))
+		metrics.delayNanos += delayNanos
+		metrics.recordMessage(len(telemetry.Data), receivedTime)
+		metrics.delayHistogram.record(delayNanos)
+		if !metrics.lastReceivedTime.IsZero() {
+			metrics.interArrivalHistogram.record(receivedTime.Sub(metrics.lastReceivedTime).Nanoseconds())
+		}
+		metrics.collectContinuity(telemetry, receivedTime)
+		if metrics.byGroundStation == nil {
+			metrics.byGroundStation = metricsBreakdown{}
+			metrics.byFraming = metricsBreakdown{}
+		}
+		metrics.byGroundStation.collect(groundStationId, len(telemetry.Data), delayNanos, receivedTime)
+		metrics.byFraming.collect(telemetry.Framing.String(), len(telemetry.Data), delayNanos, receivedTime)
 
 		// update first and last byte timestamp for the pass
 		if metrics.starpassTimeFirstByteReceived == nil || toTime(metrics.starpassTimeFirstByteReceived).After(*toTime(telemetry.TimeFirstByteReceived)) {
 			metrics.starpassTimeFirstByteReceived = telemetry.TimeFirstByteReceived
-			metrics.localTimeFirstByteReceived = timestampNow()
+			metrics.localTimeFirstByteReceived = toTimestamp(receivedTime)
 		}
 		if metrics.starpassTimeLastByteReceived == nil || toTime(metrics.starpassTimeLastByteReceived).Before(*toTime(telemetry.TimeLastByteReceived)) {
 			metrics.starpassTimeLastByteReceived = telemetry.TimeLastByteReceived
-			metrics.localTimeLastByteReceived = timestampNow()
+			metrics.localTimeLastByteReceived = toTimestamp(receivedTime)
 		}
 
 		// save details for instantaneous rates
-		msg := telemetryWithTimestamp{
-			ReceivedTime:         time.Now(),
-			DataBytes:            len(telemetry.Data),
-			TimeLastByteReceived: telemetry.TimeLastByteReceived,
-		}
-		metrics.writeLock.Lock()
-		metrics.messageBuffer = append(metrics.messageBuffer, msg)
+		metrics.messageBuffer.push(telemetryWithTimestamp{
+			ReceivedTime: receivedTime,
+			DataBytes:    len(telemetry.Data),
+			DelayNanos:   delayNanos,
+		})
 
 		// Keep 10 seconds worth of samples, but no less than InstantMinSamples samples, and no more than InstantMaxSamples; remove oldest sample if:
 		// 1. list larger than InstantMinSamples && oldest sample is older than "now - InstantSampleSeconds"
 		// 2. list larger than InstantMaxSamples
-		for (len(metrics.messageBuffer) > InstantMinSamples && metrics.messageBuffer[0].ReceivedTime.UnixNano() < time.Now().UnixNano()-(InstantSampleSeconds*1e9)) ||
-			len(metrics.messageBuffer) > InstantMaxSamples {
-			metrics.messageBuffer = metrics.messageBuffer[1:]
+		oldest := receivedTime.Add(-InstantSampleSeconds * time.Second)
+		for (metrics.messageBuffer.len() > InstantMinSamples && metrics.messageBuffer.at(0).ReceivedTime.Before(oldest)) ||
+			metrics.messageBuffer.len() > InstantMaxSamples {
+			metrics.messageBuffer.pop()
 		}
-		metrics.writeLock.Unlock()
 	}
 }
 
 // record telemetry data message received with size=messageSizeBytes
 // deprecated, kept for unit-tests
 func (metrics *MetricsCollector) collectMessage(messageSizeBytes int) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.recordMessage(messageSizeBytes, time.Now())
+}
+
+// must be called with mu held
+func (metrics *MetricsCollector) recordMessage(messageSizeBytes int, receivedTime time.Time) {
 	if metrics.totalBytesReceived == 0 {
-		metrics.timerStart = time.Now()
+		metrics.timerStart = receivedTime
 	}
 	metrics.totalMessagesReceived++
-	metrics.elapsed = time.Since(metrics.timerStart).Seconds()
+	metrics.elapsed = receivedTime.Sub(metrics.timerStart).Seconds()
 	metrics.totalBytesReceived += int64(messageSizeBytes)
 }
 
@@ -155,10 +381,14 @@ func toTime(ts *timestamp.Timestamp) *time.Time {
 }
 
 func timestampNow() *timestamp.Timestamp {
-	now := time.Now().UTC()
+	return toTimestamp(time.Now())
+}
+
+func toTimestamp(t time.Time) *timestamp.Timestamp {
+	t = t.UTC()
 	return &timestamp.Timestamp{
-		Seconds: now.Unix(),
-		Nanos:   int32(now.Nanosecond()),
+		Seconds: t.Unix(),
+		Nanos:   int32(t.Nanosecond()),
 	}
 }
 
@@ -183,8 +413,9 @@ func duration(start, end *timestamp.Timestamp) string {
 	return toTime(end).Sub(*toTime(start)).String()
 }
 
+// must be called with mu held
 func (metrics *MetricsCollector) logReport() {
-	if metrics.totalMessagesReceived > 0 {
+	if metrics.logger != nil && metrics.totalMessagesReceived > 0 {
 		// Dont use metrics.logger because it might be in overwrite mode
 		logger := fmt.Printf
 		_, _ = logger("\n\n")
@@ -205,22 +436,205 @@ func (metrics *MetricsCollector) logReport() {
 		_, _ = logger("  Total chunks          : %d\n", metrics.totalMessagesReceived)
 		_, _ = logger("  Average rate (bits/s) : %sbps\n", humanReadableCountSI(metrics.avgRate()))
 		_, _ = logger("  Average delay         : %s\n", humanReadableNanoSeconds(metrics.avgDelay()))
+		_, _ = logger("  Delay                 : %s\n", metrics.delayHistogram.report())
+		_, _ = logger("  Inter-arrival time    : %s\n", metrics.interArrivalHistogram.report())
+		_, _ = logger("  Clock offset          : %s\n", metrics.clockOffsetReport())
+		_, _ = logger("\n")
+		metrics.byGroundStation.logReport("By ground station", logger)
+		metrics.byFraming.logReport("By framing", logger)
+		_, _ = logger("  Gaps longer than %s  : %d\n", metrics.gapThreshold, len(metrics.gaps))
+		for _, gap := range metrics.gaps {
+			_, _ = logger("    %-14s : %s - %s (%s)\n", gap.Clock, gap.Start.Format(time.RFC3339), gap.End.Format(time.RFC3339),
+				gap.End.Sub(gap.Start).Truncate(time.Millisecond))
+		}
 		_, _ = logger("\n\n")
 	}
 }
 
+// print the pass report and write the pass summary file, if configured
+func (metrics *MetricsCollector) reportPass() {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.report()
+}
+
+// must be called with mu held
+func (metrics *MetricsCollector) report() {
+	metrics.logReport()
+	if metrics.onPass != nil && metrics.totalMessagesReceived > 0 {
+		metrics.onPass(metrics.summary())
+	}
+	metrics.writeSummary()
+}
+
+// summary returns the statistics of the current plan as a PassSummary. Must be called with mu held.
+func (metrics *MetricsCollector) summary() *PassSummary {
+	return &PassSummary{
+		SatelliteID:         metrics.satelliteId,
+		PlanID:              metrics.planId,
+		StreamID:            metrics.streamId,
+		GeneratedAt:         time.Now().UTC(),
+		FirstByteReceived:   toTime(metrics.starpassTimeFirstByteReceived),
+		LastByteReceived:    toTime(metrics.starpassTimeLastByteReceived),
+		FirstChunkReceived:  toTime(metrics.localTimeFirstByteReceived),
+		LastChunkReceived:   toTime(metrics.localTimeLastByteReceived),
+		TotalBytes:          metrics.totalBytesReceived,
+		TotalChunks:         metrics.totalMessagesReceived,
+		AverageRateBps:      metrics.avgRate(),
+		AverageDelaySeconds: float64(metrics.avgDelay()) / 1e9,
+		Reconnects:          metrics.reconnects,
+		Gaps:                append(make([]Gap, 0, len(metrics.gaps)), metrics.gaps...),
+		Duplicates:          metrics.duplicates,
+		GapThresholdSeconds: metrics.gapThreshold.Seconds(),
+		ByGroundStation:     metrics.byGroundStation.summary(),
+		ByFraming:           metrics.byFraming.summary(),
+		Delay:               metrics.delayHistogram.summary(),
+		InterArrival:        metrics.interArrivalHistogram.summary(),
+		ClockOffsetSeconds:  metrics.clockOffsetSeconds(),
+		ClockOffsetSource:   metrics.clockOffsetSource,
+	}
+}
+
+// the estimated clock offset in seconds, or nil if it was never measured; must be called with mu held
+func (metrics *MetricsCollector) clockOffsetSeconds() *float64 {
+	if metrics.clockOffsetSource == "" {
+		return nil
+	}
+	seconds := metrics.clockOffset.Seconds()
+	return &seconds
+}
+
+// describe the clock offset for the pass report; must be called with mu held
+func (metrics *MetricsCollector) clockOffsetReport() string {
+	if metrics.clockOffsetSource == "" {
+		return "not measured, delay uses the local clock"
+	}
+	return fmt.Sprintf("%s (measured with %s, delay corrected)", signedDuration(metrics.clockOffset), metrics.clockOffsetSource)
+}
+
+// clockOffsetStatus returns the estimated skew of the local clock for the live stats line; must be called with mu held
+func (metrics *MetricsCollector) clockOffsetStatus() string {
+	if metrics.clockOffsetSource == "" {
+		return ""
+	}
+	return fmt.Sprintf(", skew: %s", signedDuration(metrics.clockOffset.Truncate(time.Microsecond)))
+}
+
+// format d with an explicit sign, e.g. +1.5ms
+func signedDuration(d time.Duration) string {
+	if d >= 0 {
+		return "+" + d.String()
+	}
+	return d.String()
+}
+
+// write the pass summary file of the current plan, if configured; must be called with mu held
+func (metrics *MetricsCollector) writeSummary() {
+	if metrics.summaryOptions == nil || metrics.totalMessagesReceived == 0 {
+		return
+	}
+	path, err := metrics.summary().Write(metrics.summaryOptions)
+	if err != nil {
+		log.Printf("could not write pass summary: %v\n", err)
+		return
+	}
+	log.Verbose("wrote pass summary: %s\n", path)
+}
+
 // report instantaneous statistics
 func (metrics *MetricsCollector) logStats() {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
 	iDelayNanos := humanReadableNanoSeconds(metrics.instantDelay())
 	iRateStr := humanReadableCountSI(metrics.instantRate())
 	size := humanReadableBytes(metrics.totalBytesReceived)
-	if metrics.planId != "" {
-		metrics.logger("[STATS] %s, plan_id: %s, %3d msgs, bytes: %9v, rate: %9vbps, delay: %9v",
-			time.Now().Format("20060102 15:04:05"), metrics.planId, metrics.totalMessagesReceived, size, iRateStr, iDelayNanos)
+	if metrics.logger != nil && metrics.planId != "" {
+		metrics.logger("[STATS] %s, plan_id: %s, %3d msgs, bytes: %9v, rate: %9vbps, delay: %9v%s",
+			time.Now().Format("20060102 15:04:05"), metrics.planId, metrics.totalMessagesReceived, size, iRateStr, iDelayNanos,
+			metrics.latencyStatus()+metrics.clockOffsetStatus()+metrics.gapStatus(time.Now())+metrics.byGroundStation.status("stations")+metrics.byFraming.status("framings"))
 	}
 }
 
-// return avg rate for entire plan
+// latencyStatus returns the delay and inter-arrival percentiles of the plan; must be called with mu held
+func (metrics *MetricsCollector) latencyStatus() string {
+	if metrics.delayHistogram.total == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", delay p50/90/99/max: %s, inter-arrival p50/90/99/max: %s",
+		metrics.delayHistogram.status(), metrics.interArrivalHistogram.status())
+}
+
+// gapStatus returns the gap count and, while no telemetry arrives, for how long; must be called with mu held
+func (metrics *MetricsCollector) gapStatus(now time.Time) string {
+	status := ""
+	if len(metrics.gaps) > 0 {
+		status = fmt.Sprintf(", gaps: %d", len(metrics.gaps))
+	}
+	if !metrics.lastReceivedTime.IsZero() {
+		if since := now.Sub(metrics.lastReceivedTime); since > metrics.gapThreshold {
+			status += fmt.Sprintf(", NO DATA for %s", since.Truncate(time.Second))
+		}
+	}
+	return status
+}
+
+// telemetry received for a plan
+type planTotals struct {
+	bytes    int64
+	messages int64
+}
+
+// statistics of the current plan at one point in time
+type metricsSnapshot struct {
+	planId        string
+	streamId      string
+	totalBytes    int64
+	totalMessages int64
+	instantRate   int64
+	avgRate       int64
+	instantDelay  int64
+	avgDelay      int64
+	clockOffset   *float64
+	lastReceived  time.Time
+	delayP90      int64
+	reconnects    int64
+	gaps          int
+
+	// Telemetry received since the collector was created, by plan ID, including the current plan.
+	receivedByPlan map[string]planTotals
+}
+
+func (metrics *MetricsCollector) snapshot() metricsSnapshot {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	receivedByPlan := make(map[string]planTotals, len(metrics.earlierPlans)+1)
+	for planId, totals := range metrics.earlierPlans {
+		receivedByPlan[planId] = totals
+	}
+	current := receivedByPlan[metrics.planId]
+	current.bytes += metrics.totalBytesReceived
+	current.messages += metrics.totalMessagesReceived
+	receivedByPlan[metrics.planId] = current
+	return metricsSnapshot{
+		planId:         metrics.planId,
+		streamId:       metrics.streamId,
+		totalBytes:     metrics.totalBytesReceived,
+		totalMessages:  metrics.totalMessagesReceived,
+		receivedByPlan: receivedByPlan,
+		instantRate:    metrics.instantRate(),
+		avgRate:        metrics.avgRate(),
+		instantDelay:   metrics.instantDelay(),
+		avgDelay:       metrics.avgDelay(),
+		clockOffset:    metrics.clockOffsetSeconds(),
+		lastReceived:   metrics.lastReceivedTime,
+		delayP90:       metrics.delayHistogram.percentile(90),
+		reconnects:     metrics.reconnects,
+		gaps:           len(metrics.gaps),
+	}
+}
+
+// return avg delay for entire plan; the avg and instant functions must be called with mu held
 func (metrics *MetricsCollector) avgDelay() int64 {
 	if metrics.totalMessagesReceived > 0 {
 		return metrics.delayNanos / metrics.totalMessagesReceived
@@ -230,18 +644,10 @@ func (metrics *MetricsCollector) avgDelay() int64 {
 
 // returns the instantaneous data delay
 func (metrics *MetricsCollector) instantDelay() int64 {
-	if len(metrics.messageBuffer) < 2 {
+	if metrics.messageBuffer.len() < 2 {
 		return 0
 	}
-	delayNanos := int64(0)
-	metrics.writeLock.Lock()
-	defer metrics.writeLock.Unlock()
-	for _, msg := range metrics.messageBuffer {
-		if msg.TimeLastByteReceived != nil {
-			delayNanos += msg.ReceivedTime.UTC().UnixNano() - ((msg.TimeLastByteReceived.Seconds * 1e9) + int64(msg.TimeLastByteReceived.Nanos))
-		}
-	}
-	return delayNanos / int64(len(metrics.messageBuffer))
+	return metrics.messageBuffer.delayNanos / int64(metrics.messageBuffer.len())
 }
 
 // return avg rate for entire plan
@@ -254,22 +660,14 @@ func (metrics *MetricsCollector) avgRate() int64 {
 
 // returns the instantaneous data rate
 func (metrics *MetricsCollector) instantRate() int64 {
-	if len(metrics.messageBuffer) < 3 {
+	if metrics.messageBuffer.len() < 3 {
 		return 0
 	}
-	bytes := int64(0)
-	metrics.writeLock.Lock()
-	for i, msg := range metrics.messageBuffer {
-		if i > 0 {
-			// we discard the first message size, but use its ReceivedTime as the "start time" for rate calculations
-			bytes += int64(msg.DataBytes)
-		}
-	}
-	startTime := metrics.messageBuffer[0].ReceivedTime
-	lastChunk := metrics.messageBuffer[len(metrics.messageBuffer)-1]
-	endTime := lastChunk.ReceivedTime
+	// we discard the first message size, but use its ReceivedTime as the "start time" for rate calculations
+	bytes := metrics.messageBuffer.bytes - int64(metrics.messageBuffer.at(0).DataBytes)
+	startTime := metrics.messageBuffer.at(0).ReceivedTime
+	endTime := metrics.messageBuffer.at(metrics.messageBuffer.len() - 1).ReceivedTime
 	duration := float64(endTime.UnixNano()-startTime.UnixNano()) / float64(1e9)
-	metrics.writeLock.Unlock()
 	if duration == 0 {
 		return 0
 	}
@@ -339,36 +737,53 @@ func humanReadableNanoSeconds(delay int64) string {
 	return fmt.Sprintf("%.1f %s", nanos, ci[idx])
 }
 
-// StartStatsEmitScheduler this should be ran in separate thread
-func (metrics *MetricsCollector) startStatsEmitSchedulerWorker(emitRateMillis int) {
-	metrics.statsLoggingScheduler = true
+// emit stats at the given interval until stopChan is closed
+func (metrics *MetricsCollector) startStatsEmitSchedulerWorker(emitRateMillis int, stopChan, stoppedChan chan struct{}) {
+	defer close(stoppedChan)
+
 	uptimeTicker := time.NewTicker(time.Duration(emitRateMillis) * time.Millisecond)
+	defer uptimeTicker.Stop()
 	for {
-		<-uptimeTicker.C
-		if metrics.statsLoggingScheduler {
+		select {
+		case <-uptimeTicker.C:
 			// check for expired samples
-			metrics.writeLock.Lock()
-			if len(metrics.messageBuffer) > 0 {
-				lastChunk := metrics.messageBuffer[len(metrics.messageBuffer)-1]
+			metrics.mu.Lock()
+			if n := metrics.messageBuffer.len(); n > 0 {
+				lastChunk := metrics.messageBuffer.at(n - 1)
 				if lastChunk.ReceivedTime.Before(time.Now().Add(-time.Duration(InstantSampleSeconds) * time.Second)) {
-					metrics.messageBuffer = make([]telemetryWithTimestamp, 0)
+					metrics.messageBuffer.clear()
 				}
 			}
-			metrics.writeLock.Unlock()
+			metrics.mu.Unlock()
 			metrics.logStats()
-		} else {
-			// stop scheduler
+		case <-stopChan:
 			return
 		}
 	}
 }
 
-// StartStatsEmitScheduler start process to emit stats at defined interval
+// StartStatsEmitScheduler start process to emit stats at defined interval. Does nothing if it is already running.
 func (metrics *MetricsCollector) StartStatsEmitScheduler(emitRateMillis int) {
-	go metrics.startStatsEmitSchedulerWorker(emitRateMillis)
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if metrics.stopChan != nil {
+		return
+	}
+	metrics.stopChan = make(chan struct{})
+	metrics.stoppedChan = make(chan struct{})
+	go metrics.startStatsEmitSchedulerWorker(emitRateMillis, metrics.stopChan, metrics.stoppedChan)
 }
 
-// StopStatsEmitScheduler stop the emitting stats process
-func (metrics *MetricsCollector) StopStatsEmitScheduler(emitRateMillis int) {
-	metrics.statsLoggingScheduler = false
+// StopStatsEmitScheduler stop the emitting stats process and wait for it to exit. Does nothing if it is not running.
+func (metrics *MetricsCollector) StopStatsEmitScheduler() {
+	metrics.mu.Lock()
+	stopChan, stoppedChan := metrics.stopChan, metrics.stoppedChan
+	metrics.stopChan, metrics.stoppedChan = nil, nil
+	metrics.mu.Unlock()
+
+	if stopChan == nil {
+		return
+	}
+	close(stopChan)
+	<-stoppedChan
 }
@@ -18,6 +18,8 @@ package stream
 
 import (
 	"fmt"
+	"math"
+	"sync"
 	"testing"
 	"time"
 
@@ -36,12 +38,12 @@ func assertEqual(t *testing.T, a interface{}, b interface{}, message string) {
 }
 
 func TestMetricLogging(t *testing.T) {
-	metrics := *NewMetricsCollector(t.Logf)
+	metrics := NewMetricsCollector(t.Logf)
 	metrics.setPlanId("plan1")
 	for i := 1; i <= 10e17; i *= 4 {
 		metrics.collectMessage(i)
 		t := time.Now().Add(-time.Duration(i) * time.Nanosecond)
-		metrics.collectTelemetry(&stellarstation.Telemetry{
+		metrics.collectTelemetry("gs_1", &stellarstation.Telemetry{
 			Data:                  make([]byte, 5),
 			TimeFirstByteReceived: ToTimestamp(&t),
 			TimeLastByteReceived:  ToTimestamp(&t),
@@ -52,7 +54,7 @@ func TestMetricLogging(t *testing.T) {
 }
 
 func TestReset(t *testing.T) {
-	metrics := *NewMetricsCollector(t.Logf)
+	metrics := NewMetricsCollector(t.Logf)
 	metrics.setPlanId("plan1")
 	metrics.collectMessage(100)
 	metrics.logStats()
@@ -94,14 +96,197 @@ func createTelemetry(start *time.Time, durationMillis int) *stellarstation.Telem
 }
 
 func TestReport(t *testing.T) {
-	metrics := *NewMetricsCollector(t.Logf)
+	metrics := NewMetricsCollector(t.Logf)
 	metrics.setPlanId("test_plan_1")
 	metrics.setStreamId("stream_1")
 	start := time.Now().Add(-time.Duration(5) * time.Minute)
 	for i := 0; i < 10; i++ {
 		start = start.Add(time.Millisecond * time.Duration(i*2000))
 		t1 := createTelemetry(&start, 2000)
-		metrics.collectTelemetry(t1)
+		metrics.collectTelemetry("gs_1", t1)
 	}
-	metrics.logReport()
+	metrics.reportPass()
+}
+
+func TestConcurrentCollection(t *testing.T) {
+	metrics := NewMetricsCollector(t.Logf)
+	metrics.StartStatsEmitScheduler(1)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		start := time.Now()
+		for i := 0; i < 100; i++ {
+			metrics.setPlanId(fmt.Sprintf("plan%d", i/50))
+			chunkStart := start.Add(time.Duration(i) * time.Millisecond)
+			metrics.collectTelemetry("gs_1", createTelemetry(&chunkStart, 1))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			metrics.setStreamId("stream_1")
+			_ = metrics.snapshot()
+		}
+	}()
+	wg.Wait()
+
+	metrics.StopStatsEmitScheduler()
+	// Stopping twice is a no-op.
+	metrics.StopStatsEmitScheduler()
+	assertEqual(t, metrics.snapshot().totalMessages, int64(50), "")
+}
+
+func TestIndependentCollectors(t *testing.T) {
+	metrics1 := NewMetricsCollector(nil)
+	metrics2 := NewMetricsCollector(nil)
+	metrics1.setPlanId("plan1")
+	metrics2.setPlanId("plan2")
+	metrics1.collectMessage(10)
+
+	assertEqual(t, metrics1.snapshot().totalBytes, int64(10), "")
+	assertEqual(t, metrics2.snapshot().totalBytes, int64(0), "")
+	assertEqual(t, metrics2.snapshot().planId, "plan2", "")
+}
+
+func TestContinuity(t *testing.T) {
+	metrics := NewMetricsCollector(nil)
+	metrics.setPlanId("plan1")
+	start := time.Now()
+	telemetry := createTelemetry(&start, 100)
+
+	metrics.collectContinuity(telemetry, start)
+	metrics.collectContinuity(telemetry, start.Add(time.Second))
+	assertEqual(t, metrics.duplicates, int64(1), "")
+	assertEqual(t, len(metrics.gaps), 0, "")
+
+	// Received late, but without a gap at the ground station.
+	next := start.Add(100 * time.Millisecond)
+	received := start.Add(time.Second + DefaultGapThreshold + time.Millisecond)
+	metrics.collectContinuity(createTelemetry(&next, 100), received)
+	assertEqual(t, metrics.duplicates, int64(1), "")
+	assertEqual(t, len(metrics.gaps), 1, "")
+	assertEqual(t, metrics.gaps[0].Clock, GapClockLocal, "")
+	assertEqual(t, metrics.gaps[0].End.Equal(received), true, "")
+
+	// A gap at the ground station, received without delay.
+	metrics.setGapThreshold(time.Minute)
+	later := next.Add(2 * time.Minute)
+	metrics.collectContinuity(createTelemetry(&later, 100), received.Add(time.Second))
+	assertEqual(t, len(metrics.gaps), 2, "")
+	assertEqual(t, metrics.gaps[1].Clock, GapClockGroundStation, "")
+	assertEqual(t, metrics.gaps[1].Start.Equal(next.Add(100*time.Millisecond)), true, "")
+	assertEqual(t, metrics.gaps[1].End.Equal(later), true, "")
+}
+
+func TestGapStatus(t *testing.T) {
+	metrics := NewMetricsCollector(nil)
+	metrics.setPlanId("plan1")
+	now := time.Now()
+	metrics.collectContinuity(createTelemetry(&now, 100), now)
+	assertEqual(t, metrics.gapStatus(now.Add(time.Second)), "", "")
+	assertEqual(t, metrics.gapStatus(now.Add(DefaultGapThreshold+2*time.Second)), ", NO DATA for 12s", "")
+}
+
+func TestBreakdown(t *testing.T) {
+	metrics := NewMetricsCollector(nil)
+	metrics.setPlanId("plan1")
+	now := time.Now()
+	for i, gs := range []string{"gs_1", "gs_2", "gs_1"} {
+		telemetry := createTelemetry(&now, 100)
+		telemetry.Data = make([]byte, 10*(i+1))
+		if i == 1 {
+			telemetry.Framing = stellarstation.Framing_AX25
+		}
+		metrics.collectTelemetry(gs, telemetry)
+	}
+
+	metrics.mu.Lock()
+	summary := metrics.summary()
+	metrics.mu.Unlock()
+	assertEqual(t, len(summary.ByGroundStation), 2, "")
+	assertEqual(t, summary.ByGroundStation[0].Name, "gs_1", "")
+	assertEqual(t, summary.ByGroundStation[0].TotalBytes, int64(40), "")
+	assertEqual(t, summary.ByGroundStation[0].TotalChunks, int64(2), "")
+	assertEqual(t, summary.ByGroundStation[1].TotalBytes, int64(20), "")
+	assertEqual(t, len(summary.ByFraming), 2, "")
+	assertEqual(t, summary.ByFraming[0].Name, "AX25", "")
+	assertEqual(t, summary.ByFraming[1].Name, "BITSTREAM", "")
+
+	// The live status only shows a breakdown split over several keys.
+	assertEqual(t, metricsBreakdown{"gs_1": {totalBytes: 10}}.status("stations"), "", "")
+	breakdown := metricsBreakdown{}
+	breakdown.collect("gs_2", 1000, 0, now)
+	breakdown.collect("gs_2", 1000, 0, now.Add(time.Second))
+	breakdown.collect("gs_1", 20, 0, now)
+	assertEqual(t, breakdown.status("stations"), ", stations: [gs_1: 20 B 0 bps, gs_2: 2.0 KiB 16.0 kbps]", "")
+}
+
+func TestClockOffset(t *testing.T) {
+	metrics := NewMetricsCollector(nil)
+	metrics.setPlanId("plan1")
+	metrics.setClockOffset(time.Hour, "ntp.example.com")
+	now := time.Now()
+	metrics.collectTelemetry("gs_1", createTelemetry(&now, 0))
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	delay := time.Duration(metrics.avgDelay())
+	if delay < time.Hour || delay > time.Hour+time.Second {
+		t.Errorf("delay not corrected for the clock offset: %v", delay)
+	}
+	summary := metrics.summary()
+	assertEqual(t, *summary.ClockOffsetSeconds, 3600.0, "")
+	assertEqual(t, summary.ClockOffsetSource, "ntp.example.com", "")
+	assertEqual(t, metrics.clockOffsetStatus(), ", skew: +1h0m0s", "")
+	assertEqual(t, signedDuration(-1500*time.Microsecond), "-1.5ms", "")
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	h := latencyHistogram{}
+	assertEqual(t, h.percentile(50), int64(0), "")
+	for i := int64(1); i <= 1000; i++ {
+		h.record(i * int64(time.Millisecond))
+	}
+	h.record(-1)
+
+	// values are exact to within 1/64
+	within := func(got, want int64) {
+		if got < want || got > want+want/64 {
+			t.Errorf("got %d, want %d to within 1/64", got, want)
+		}
+	}
+	within(h.percentile(50), 500*int64(time.Millisecond))
+	within(h.percentile(90), 900*int64(time.Millisecond))
+	within(h.percentile(99), 990*int64(time.Millisecond))
+	assertEqual(t, h.percentile(100), int64(time.Second), "")
+	assertEqual(t, h.percentile(0), int64(0), "")
+	assertEqual(t, h.summary().Count, int64(1001), "")
+	assertEqual(t, h.summary().MaxSeconds, 1.0, "")
+
+	for _, v := range []int64{0, 127, 128, 1000, 1 << 40, math.MaxInt64} {
+		if got := histogramValue(histogramIndex(v)); got < v {
+			t.Errorf("bucket of %d ends at %d", v, got)
+		}
+	}
+	assertEqual(t, histogramIndex(math.MaxInt64), histogramBucketCount-1, "")
+}
+
+func TestSampleRing(t *testing.T) {
+	r := sampleRing{}
+	for i := 0; i < 100; i++ {
+		r.push(telemetryWithTimestamp{DataBytes: i, DelayNanos: int64(i)})
+		if r.len() > 10 {
+			r.pop()
+		}
+	}
+	assertEqual(t, r.len(), 10, "")
+	assertEqual(t, r.at(0).DataBytes, 90, "")
+	assertEqual(t, r.at(9).DataBytes, 99, "")
+	assertEqual(t, r.bytes, int64(945), "")
+	assertEqual(t, r.delayNanos, int64(945), "")
+	r.clear()
+	assertEqual(t, r.len(), 0, "")
+	assertEqual(t, r.bytes, int64(0), "")
 }
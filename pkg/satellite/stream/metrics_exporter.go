@@ -0,0 +1,209 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/infostellarinc/stellarcli/pkg/logger"
+)
+
+// StreamCounters counts stream events that are not part of the telemetry statistics. The methods are safe for
+// concurrent use and do nothing on a nil StreamCounters.
+type StreamCounters struct {
+	reconnects      atomic.Int64
+	proxyClients    atomic.Int64
+	commandsSent    atomic.Int64
+	commandsFailed  atomic.Int64
+	commandsDropped atomic.Int64
+	droppedFrames   atomic.Int64
+}
+
+func (c *StreamCounters) addReconnect() {
+	if c != nil {
+		c.reconnects.Add(1)
+	}
+}
+
+func (c *StreamCounters) setProxyClients(n int) {
+	if c != nil {
+		c.proxyClients.Store(int64(n))
+	}
+}
+
+// count a command by its outcome, one of CommandSent, CommandFailed or CommandDropped
+func (c *StreamCounters) addCommand(outcome string) {
+	if c == nil {
+		return
+	}
+	switch outcome {
+	case CommandSent:
+		c.commandsSent.Add(1)
+	case CommandFailed:
+		c.commandsFailed.Add(1)
+	case CommandDropped:
+		c.commandsDropped.Add(1)
+	}
+}
+
+func (c *StreamCounters) addDroppedFrame() {
+	if c != nil {
+		c.droppedFrames.Add(1)
+	}
+}
+
+// MetricsExporter serves the statistics of a stream over HTTP in the Prometheus text exposition format.
+type MetricsExporter struct {
+	listener net.Listener
+	server   *http.Server
+	counters StreamCounters
+
+	mu          sync.Mutex
+	satelliteId string
+	collector   *MetricsCollector
+}
+
+// NewMetricsExporter starts serving metrics at http://addr/metrics.
+func NewMetricsExporter(addr string) (*MetricsExporter, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &MetricsExporter{
+		listener: listener,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", e.serveMetrics)
+	e.server = &http.Server{Handler: mux}
+
+	go func() {
+		if err := e.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("metrics endpoint stopped: %v\n", err)
+		}
+	}()
+	log.Printf("serving metrics at http://%s/metrics\n", listener.Addr())
+
+	return e, nil
+}
+
+// Addr returns the address the exporter listens on.
+func (e *MetricsExporter) Addr() net.Addr {
+	return e.listener.Addr()
+}
+
+// Close stops serving metrics.
+func (e *MetricsExporter) Close() error {
+	return e.server.Close()
+}
+
+// streamCounters returns the counters updated by the stream and its proxy, or nil if e is nil.
+func (e *MetricsExporter) streamCounters() *StreamCounters {
+	if e == nil {
+		return nil
+	}
+	return &e.counters
+}
+
+// export the statistics of collector for the given satellite
+func (e *MetricsExporter) setCollector(satelliteId string, collector *MetricsCollector) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.satelliteId = satelliteId
+	e.collector = collector
+}
+
+func (e *MetricsExporter) serveMetrics(w http.ResponseWriter, _ *http.Request) {
+	e.mu.Lock()
+	satelliteId := e.satelliteId
+	collector := e.collector
+	e.mu.Unlock()
+
+	var s metricsSnapshot
+	if collector != nil {
+		s = collector.snapshot()
+	}
+
+	planLabels := func(planId string) string {
+		return fmt.Sprintf(`{satellite_id="%s",plan_id="%s",stream_id="%s"}`,
+			escapeLabelValue(satelliteId), escapeLabelValue(planId), escapeLabelValue(s.streamId))
+	}
+	labels := planLabels(s.planId)
+
+	var b bytes.Buffer
+	writeMetric := func(name, metricType, help string, value interface{}) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s%s %v\n", name, help, name, metricType, name, labels, value)
+	}
+	// The received totals have a series per plan, which keeps counting when the stream moves on to another plan.
+	planIds := make([]string, 0, len(s.receivedByPlan))
+	for planId := range s.receivedByPlan {
+		planIds = append(planIds, planId)
+	}
+	sort.Strings(planIds)
+	if len(planIds) == 0 {
+		planIds = append(planIds, s.planId)
+	}
+	writePlanMetric := func(name, help string, value func(planTotals) int64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, planId := range planIds {
+			fmt.Fprintf(&b, "%s%s %d\n", name, planLabels(planId), value(s.receivedByPlan[planId]))
+		}
+	}
+	writePlanMetric("stellar_stream_received_bytes_total", "Telemetry bytes received for the plan.",
+		func(t planTotals) int64 { return t.bytes })
+	writePlanMetric("stellar_stream_received_messages_total", "Telemetry messages received for the plan.",
+		func(t planTotals) int64 { return t.messages })
+	writeMetric("stellar_stream_rate_bits_per_second", "gauge",
+		"Instantaneous telemetry data rate.", s.instantRate)
+	writeMetric("stellar_stream_average_rate_bits_per_second", "gauge",
+		"Average telemetry data rate for the current plan.", s.avgRate)
+	writeMetric("stellar_stream_delay_seconds", "gauge",
+		"Instantaneous delay between the ground station receiving telemetry and the CLI receiving it.",
+		float64(s.instantDelay)/1e9)
+	writeMetric("stellar_stream_average_delay_seconds", "gauge",
+		"Average telemetry delay for the current plan.", float64(s.avgDelay)/1e9)
+	if s.clockOffset != nil {
+		writeMetric("stellar_stream_clock_offset_seconds", "gauge",
+			"Estimated offset of the local clock the delay is corrected for.", *s.clockOffset)
+	}
+	writeMetric("stellar_stream_reconnects_total", "counter",
+		"Reconnections to the API stream.", e.counters.reconnects.Load())
+	writeMetric("stellar_stream_proxy_clients", "gauge",
+		"Clients connected to the TCP proxy.", e.counters.proxyClients.Load())
+	writeMetric("stellar_stream_commands_sent_total", "counter",
+		"Commands sent to the satellite.", e.counters.commandsSent.Load())
+	writeMetric("stellar_stream_commands_failed_total", "counter",
+		"Commands that could not be sent to the satellite.", e.counters.commandsFailed.Load())
+	writeMetric("stellar_stream_commands_dropped_total", "counter",
+		"Commands dropped from a full or closed command queue.", e.counters.commandsDropped.Load())
+	writeMetric("stellar_stream_dropped_frames_total", "counter",
+		"Received frames that could not be delivered to a consumer or spooled.", e.counters.droppedFrames.Load())
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(b.Bytes())
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
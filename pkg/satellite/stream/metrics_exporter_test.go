@@ -0,0 +1,151 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMetricsExporter(t *testing.T) {
+	e, err := NewMetricsExporter("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	collector := NewMetricsCollector(nil)
+	collector.setPlanId("plan_1")
+	collector.setStreamId("stream_1")
+	collector.collectMessage(100)
+	e.setCollector("sat_1", collector)
+
+	counters := e.streamCounters()
+	counters.addReconnect()
+	counters.setProxyClients(2)
+	counters.addCommand(CommandSent)
+	counters.addCommand(CommandDropped)
+	counters.addDroppedFrame()
+
+	resp, err := http.Get("http://" + e.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labels := `{satellite_id="sat_1",plan_id="plan_1",stream_id="stream_1"}`
+	for _, line := range []string{
+		"# TYPE stellar_stream_received_bytes_total counter",
+		"stellar_stream_received_bytes_total" + labels + " 100",
+		"stellar_stream_received_messages_total" + labels + " 1",
+		"stellar_stream_reconnects_total" + labels + " 1",
+		"stellar_stream_proxy_clients" + labels + " 2",
+		"stellar_stream_commands_sent_total" + labels + " 1",
+		"stellar_stream_commands_failed_total" + labels + " 0",
+		"stellar_stream_commands_dropped_total" + labels + " 1",
+		"stellar_stream_dropped_frames_total" + labels + " 1",
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestMetricsExporterPlanChange(t *testing.T) {
+	e, err := NewMetricsExporter("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	collector := NewMetricsCollector(nil)
+	collector.setStreamId("stream_1")
+	collector.setPlanId("plan_1")
+	collector.collectMessage(100)
+	collector.setPlanId("plan_2")
+	collector.collectMessage(50)
+	collector.setPlanId("plan_1")
+	collector.collectMessage(10)
+	e.setCollector("sat_1", collector)
+
+	resp, err := http.Get("http://" + e.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The totals of a plan are not reset when the stream moves on to another plan.
+	for _, line := range []string{
+		`stellar_stream_received_bytes_total{satellite_id="sat_1",plan_id="plan_1",stream_id="stream_1"} 110`,
+		`stellar_stream_received_bytes_total{satellite_id="sat_1",plan_id="plan_2",stream_id="stream_1"} 50`,
+		`stellar_stream_received_messages_total{satellite_id="sat_1",plan_id="plan_1",stream_id="stream_1"} 2`,
+		`stellar_stream_received_messages_total{satellite_id="sat_1",plan_id="plan_2",stream_id="stream_1"} 1`,
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+	if n := strings.Count(string(body), "# TYPE stellar_stream_received_bytes_total counter"); n != 1 {
+		t.Errorf("received bytes described %d times", n)
+	}
+}
+
+func TestMetricsExporterScrapeWhileCollecting(t *testing.T) {
+	e, err := NewMetricsExporter("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	collector := NewMetricsCollector(nil)
+	e.setCollector("sat_1", collector)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		start := time.Now()
+		for i := 0; i < 100; i++ {
+			collector.setPlanId("plan_1")
+			chunkStart := start.Add(time.Duration(i) * time.Millisecond)
+			collector.collectTelemetry("gs_1", createTelemetry(&chunkStart, 1))
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		resp, err := http.Get("http://" + e.Addr().String() + "/metrics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	wg.Wait()
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	assertEqual(t, escapeLabelValue(`a"b\c`+"\n"), `a\"b\\c\n`, "")
+}
@@ -0,0 +1,142 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+)
+
+// Buckets of latencyHistogram: values below 2^histogramSubBucketBits have a bucket each, larger values are split
+// into histogramSubBucketHalf buckets per power of two, which keeps the relative error of any value below 1/64.
+const (
+	histogramSubBucketBits  = 7
+	histogramSubBucketCount = 1 << histogramSubBucketBits
+	histogramSubBucketHalf  = histogramSubBucketCount / 2
+	histogramBucketCount    = histogramSubBucketCount + (63-histogramSubBucketBits)*histogramSubBucketHalf
+)
+
+// LatencyPercentiles summarizes a distribution of durations.
+type LatencyPercentiles struct {
+	Count      int64   `json:"count" yaml:"count"`
+	P50Seconds float64 `json:"p50_seconds" yaml:"p50_seconds"`
+	P90Seconds float64 `json:"p90_seconds" yaml:"p90_seconds"`
+	P99Seconds float64 `json:"p99_seconds" yaml:"p99_seconds"`
+	MaxSeconds float64 `json:"max_seconds" yaml:"max_seconds"`
+}
+
+// latencyHistogram is an HDR-style histogram of durations in nanoseconds. It uses a fixed amount of memory
+// regardless of the number of values recorded, and recording is constant time.
+type latencyHistogram struct {
+	// allocated on the first record
+	counts []int64
+	total  int64
+	max    int64
+}
+
+// bucket index of v, which must not be negative
+func histogramIndex(v int64) int {
+	if v < histogramSubBucketCount {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - histogramSubBucketBits
+	mantissa := int(v >> shift)
+	return histogramSubBucketCount + (shift-1)*histogramSubBucketHalf + mantissa - histogramSubBucketHalf
+}
+
+// highest value that falls in the bucket at index
+func histogramValue(index int) int64 {
+	if index < histogramSubBucketCount {
+		return int64(index)
+	}
+	shift := (index-histogramSubBucketCount)/histogramSubBucketHalf + 1
+	mantissa := int64((index-histogramSubBucketCount)%histogramSubBucketHalf + histogramSubBucketHalf)
+	if mantissa+1 > math.MaxInt64>>shift {
+		return math.MaxInt64
+	}
+	return (mantissa+1)<<shift - 1
+}
+
+// record a duration in nanoseconds; negative durations, caused by an unsynchronized clock, are counted as zero
+func (h *latencyHistogram) record(nanos int64) {
+	if nanos < 0 {
+		nanos = 0
+	}
+	if h.counts == nil {
+		h.counts = make([]int64, histogramBucketCount)
+	}
+	h.counts[histogramIndex(nanos)]++
+	h.total++
+	if nanos > h.max {
+		h.max = nanos
+	}
+}
+
+// percentile returns the value below or at which p percent of the recorded values fall, or 0 if none were
+// recorded. The result is never more than the largest value recorded.
+func (h *latencyHistogram) percentile(p float64) int64 {
+	if h.total == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(p / 100 * float64(h.total)))
+	if rank < 1 {
+		rank = 1
+	}
+	seen := int64(0)
+	for i, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			if v := histogramValue(i); v < h.max {
+				return v
+			}
+			return h.max
+		}
+	}
+	return h.max
+}
+
+func (h *latencyHistogram) summary() LatencyPercentiles {
+	return LatencyPercentiles{
+		Count:      h.total,
+		P50Seconds: float64(h.percentile(50)) / 1e9,
+		P90Seconds: float64(h.percentile(90)) / 1e9,
+		P99Seconds: float64(h.percentile(99)) / 1e9,
+		MaxSeconds: float64(h.max) / 1e9,
+	}
+}
+
+// status returns p50/p90/p99/max for the live stats line
+func (h *latencyHistogram) status() string {
+	values := []int64{h.percentile(50), h.percentile(90), h.percentile(99), h.max}
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strings.TrimSpace(humanReadableNanoSeconds(v))
+	}
+	return strings.Join(parts, "/")
+}
+
+// report returns the percentiles for the pass report
+func (h *latencyHistogram) report() string {
+	if h.total == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("p50 %s, p90 %s, p99 %s, max %s",
+		strings.TrimSpace(humanReadableNanoSeconds(h.percentile(50))),
+		strings.TrimSpace(humanReadableNanoSeconds(h.percentile(90))),
+		strings.TrimSpace(humanReadableNanoSeconds(h.percentile(99))),
+		strings.TrimSpace(humanReadableNanoSeconds(h.max)))
+}
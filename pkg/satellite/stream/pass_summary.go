@@ -0,0 +1,139 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of pass summary files.
+const (
+	PassSummaryJSON = "json"
+	PassSummaryYAML = "yaml"
+)
+
+// Clocks reception gaps are measured by.
+const (
+	// The time the CLI received the telemetry.
+	GapClockLocal = "local"
+	// The times the ground station received the telemetry.
+	GapClockGroundStation = "ground_station"
+)
+
+// Gap is an interval during a plan without any telemetry.
+type Gap struct {
+	Clock           string    `json:"clock" yaml:"clock"`
+	Start           time.Time `json:"start" yaml:"start"`
+	End             time.Time `json:"end" yaml:"end"`
+	DurationSeconds float64   `json:"duration_seconds" yaml:"duration_seconds"`
+}
+
+func newGap(clock string, start, end time.Time) Gap {
+	return Gap{
+		Clock:           clock,
+		Start:           start.UTC(),
+		End:             end.UTC(),
+		DurationSeconds: end.Sub(start).Seconds(),
+	}
+}
+
+// PassSummaryOptions configures writing a pass summary file for every plan received on a stream.
+type PassSummaryOptions struct {
+	// Directory the summaries are written to.
+	Dir string
+	// Format of the summaries, PassSummaryJSON or PassSummaryYAML.
+	Format string
+}
+
+// PassSummary holds the statistics of one plan received on a stream.
+type PassSummary struct {
+	SatelliteID string    `json:"satellite_id" yaml:"satellite_id"`
+	PlanID      string    `json:"plan_id" yaml:"plan_id"`
+	StreamID    string    `json:"stream_id" yaml:"stream_id"`
+	GeneratedAt time.Time `json:"generated_at" yaml:"generated_at"`
+
+	// Times reported by the ground station.
+	FirstByteReceived *time.Time `json:"first_byte_received,omitempty" yaml:"first_byte_received,omitempty"`
+	LastByteReceived  *time.Time `json:"last_byte_received,omitempty" yaml:"last_byte_received,omitempty"`
+	// Local times the CLI received the chunks holding the first and last byte.
+	FirstChunkReceived *time.Time `json:"first_chunk_received,omitempty" yaml:"first_chunk_received,omitempty"`
+	LastChunkReceived  *time.Time `json:"last_chunk_received,omitempty" yaml:"last_chunk_received,omitempty"`
+
+	TotalBytes          int64   `json:"total_bytes" yaml:"total_bytes"`
+	TotalChunks         int64   `json:"total_chunks" yaml:"total_chunks"`
+	AverageRateBps      int64   `json:"average_rate_bps" yaml:"average_rate_bps"`
+	AverageDelaySeconds float64 `json:"average_delay_seconds" yaml:"average_delay_seconds"`
+	Reconnects          int64   `json:"reconnects" yaml:"reconnects"`
+	Duplicates          int64   `json:"duplicates" yaml:"duplicates"`
+	// Distributions of the delay of each chunk and of the local time between consecutive chunks.
+	Delay        LatencyPercentiles `json:"delay" yaml:"delay"`
+	InterArrival LatencyPercentiles `json:"inter_arrival" yaml:"inter_arrival"`
+	// Gaps longer than GapThresholdSeconds, measured by both clocks.
+	GapThresholdSeconds float64 `json:"gap_threshold_seconds" yaml:"gap_threshold_seconds"`
+	Gaps                []Gap   `json:"gaps" yaml:"gaps"`
+
+	// Statistics of the telemetry received from each ground station and with each framing.
+	ByGroundStation []PassBreakdown `json:"by_ground_station" yaml:"by_ground_station"`
+	ByFraming       []PassBreakdown `json:"by_framing" yaml:"by_framing"`
+
+	// Estimated offset of the local clock the delays are corrected for, and the NTP server it was measured with.
+	// Absent when the offset was not measured and delays use the local clock as is.
+	ClockOffsetSeconds *float64 `json:"clock_offset_seconds,omitempty" yaml:"clock_offset_seconds,omitempty"`
+	ClockOffsetSource  string   `json:"clock_offset_source,omitempty" yaml:"clock_offset_source,omitempty"`
+}
+
+// Write the summary to a file in o.Dir named after the plan and the time the summary was generated, and return
+// the path of the file.
+func (s *PassSummary) Write(o *PassSummaryOptions) (string, error) {
+	var data []byte
+	var err error
+	switch o.Format {
+	case PassSummaryYAML:
+		data, err = yaml.Marshal(s)
+	case PassSummaryJSON, "":
+		data, err = json.MarshalIndent(s, "", "  ")
+		data = append(data, '\n')
+	default:
+		return "", fmt.Errorf("unsupported pass summary format: %v", o.Format)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	ext := o.Format
+	if ext == "" {
+		ext = PassSummaryJSON
+	}
+	name := fmt.Sprintf("%s_%s.%s", s.PlanID, s.GeneratedAt.UTC().Format("20060102T150405Z"), ext)
+	path := filepath.Join(o.Dir, name)
+
+	// Write to a temporary file first so that consumers watching the directory never see a partial summary.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+
+	return path, nil
+}
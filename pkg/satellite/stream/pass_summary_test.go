@@ -0,0 +1,86 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestPassSummaryWrittenOnPlanChange(t *testing.T) {
+	dir := t.TempDir()
+	metrics := NewMetricsCollector(nil)
+	metrics.setSummaryOptions("sat_1", &PassSummaryOptions{Dir: dir, Format: PassSummaryJSON})
+	metrics.setPlanId("plan_1")
+	metrics.setStreamId("stream_1")
+	start := time.Now().Add(-time.Minute)
+	for i := 0; i < 3; i++ {
+		chunkStart := start.Add(time.Duration(i) * time.Second)
+		metrics.collectTelemetry("gs_1", createTelemetry(&chunkStart, 1000))
+	}
+	metrics.collectReconnect()
+	metrics.setPlanId("plan_2")
+
+	files, err := filepath.Glob(filepath.Join(dir, "plan_1_*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(files), 1, "")
+
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary PassSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, summary.SatelliteID, "sat_1", "")
+	assertEqual(t, summary.StreamID, "stream_1", "")
+	assertEqual(t, summary.TotalBytes, int64(15), "")
+	assertEqual(t, summary.TotalChunks, int64(3), "")
+	assertEqual(t, summary.Reconnects, int64(1), "")
+	assertEqual(t, summary.FirstByteReceived.Equal(start), true, "")
+
+	// Nothing is written for a plan without telemetry.
+	metrics.reportPass()
+	files, _ = filepath.Glob(filepath.Join(dir, "plan_2_*"))
+	assertEqual(t, len(files), 0, "")
+}
+
+func TestPassSummaryYAML(t *testing.T) {
+	s := &PassSummary{PlanID: "plan_1", GeneratedAt: time.Now(), TotalBytes: 10}
+	path, err := s.Write(&PassSummaryOptions{Dir: t.TempDir(), Format: PassSummaryYAML})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, filepath.Ext(path), ".yaml", "")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded PassSummary
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, decoded.PlanID, "plan_1", "")
+	assertEqual(t, decoded.TotalBytes, int64(10), "")
+}
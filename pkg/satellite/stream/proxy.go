@@ -21,3 +21,26 @@ type Proxy interface {
 	// Start listening for packets to send to the satellite and sending back received packets.
 	Start(o *SatelliteStreamOptions) (func(), error)
 }
+
+// Frame is a telemetry payload handed to a proxy. The proxy must call Done once the payload has been delivered to
+// a consumer or spooled so the stream can acknowledge the message to the API, or Fail if it could do neither.
+type Frame struct {
+	Data []byte
+
+	done func()
+	fail func()
+}
+
+// Done marks the frame as delivered.
+func (f *Frame) Done() {
+	if f.done != nil {
+		f.done()
+	}
+}
+
+// Fail marks the frame as undelivered. The message it belongs to is not acknowledged.
+func (f *Frame) Fail() {
+	if f.fail != nil {
+		f.fail()
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	log "github.com/infostellarinc/stellarcli/pkg/logger"
+)
+
+// ErrSpoolFull is returned when writing a frame would exceed the spool size limit.
+var ErrSpoolFull = errors.New("spool is full")
+
+// Size of the length prefix of a spooled frame.
+const spoolRecordHeaderSize = 4
+
+// Characters replaced in the spool name, which comes from the command line, to keep the file in the spool directory.
+var unsafeSpoolNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// Interval spooled frames are synced to disk at.
+const spoolSyncInterval = time.Second
+
+type SpoolOptions struct {
+	// Directory holding the spool file.
+	Dir string
+	// Name of the spool, typically the satellite ID.
+	Name string
+	// Maximum size of the spool file in bytes.
+	MaxSize int64
+}
+
+// Spool is a disk-backed FIFO buffering telemetry frames while no consumer is attached. Frames survive restarts of
+// the CLI and are replayed in order to the next consumer.
+type Spool struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	size    int64
+	maxSize int64
+	frames  int
+	dropped uint64
+	// Set when frames have been written since the file was last synced.
+	dirty bool
+
+	// Serializes replays, which read the spool without holding mu while frames are handed to the consumer.
+	replayLock sync.Mutex
+
+	closeChan    chan struct{}
+	syncLoopDone chan struct{}
+}
+
+// OpenSpool opens the spool file, creating it if needed. Frames left by a previous run are kept for replay.
+func OpenSpool(o *SpoolOptions) (*Spool, error) {
+	if err := os.MkdirAll(o.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create spool directory: %w", err)
+	}
+
+	path := filepath.Join(o.Dir, unsafeSpoolNameChars.ReplaceAllString(o.Name, "_")+".spool")
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open spool: %w", err)
+	}
+
+	s := &Spool{
+		path:         path,
+		file:         file,
+		maxSize:      o.MaxSize,
+		closeChan:    make(chan struct{}),
+		syncLoopDone: make(chan struct{}),
+	}
+	if err := s.recover(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if s.frames > 0 {
+		log.Printf("spool %s holds %d frames (%s) from a previous run.\n", path, s.frames, humanReadableBytes(s.size))
+	}
+
+	go s.syncLoop()
+
+	return s, nil
+}
+
+// syncLoop syncs written frames to disk every spoolSyncInterval until the spool is closed.
+func (s *Spool) syncLoop() {
+	defer close(s.syncLoopDone)
+
+	ticker := time.NewTicker(spoolSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			if err := s.sync(); err != nil {
+				log.PrintlnThrottled("could not sync spool: %v", err)
+			}
+			s.mu.Unlock()
+		case <-s.closeChan:
+			return
+		}
+	}
+}
+
+// sync the spool file to disk if frames have been written since the last sync.
+func (s *Spool) sync() error {
+	if !s.dirty {
+		return nil
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// recover counts the frames in the spool file and drops a partially written frame at its end.
+func (s *Spool) recover() error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return fmt.Errorf("could not read spool: %w", err)
+	}
+
+	offset := int64(0)
+	header := make([]byte, spoolRecordHeaderSize)
+	for offset+spoolRecordHeaderSize <= info.Size() {
+		if _, err := s.file.ReadAt(header, offset); err != nil {
+			return fmt.Errorf("could not read spool: %w", err)
+		}
+		next := offset + spoolRecordHeaderSize + int64(binary.BigEndian.Uint32(header))
+		if next > info.Size() {
+			break
+		}
+		offset = next
+		s.frames++
+	}
+
+	if offset != info.Size() {
+		log.Printf("dropping %d bytes of incomplete data at the end of spool %s.\n", info.Size()-offset, s.path)
+		if err := s.file.Truncate(offset); err != nil {
+			return fmt.Errorf("could not repair spool: %w", err)
+		}
+	}
+	s.size = offset
+
+	return nil
+}
+
+// Write appends a frame to the spool. Frames are synced to disk periodically rather than on every write, so a crash
+// of the machine can lose the frames written during the last spoolSyncInterval. Frames that do not fit within the
+// size limit are dropped and ErrSpoolFull is returned.
+func (s *Spool) Write(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recordSize := int64(spoolRecordHeaderSize + len(data))
+	if s.maxSize > 0 && s.size+recordSize > s.maxSize {
+		s.dropped++
+		return ErrSpoolFull
+	}
+
+	record := make([]byte, recordSize)
+	binary.BigEndian.PutUint32(record, uint32(len(data)))
+	copy(record[spoolRecordHeaderSize:], data)
+
+	if _, err := s.file.WriteAt(record, s.size); err != nil {
+		s.dropped++
+		return err
+	}
+	s.dirty = true
+	s.size += recordSize
+	s.frames++
+
+	return nil
+}
+
+// Len returns the number of frames in the spool.
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.frames
+}
+
+// Dropped returns the number of frames that could not be spooled.
+func (s *Spool) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.dropped
+}
+
+// Replay calls fn for every spooled frame in order, including frames written while the replay runs. Frames are
+// removed from the spool once fn returns nil. If fn returns an error, replay stops and the remaining frames are kept.
+// The spool is not locked while fn runs, so frames can be written concurrently.
+func (s *Spool) Replay(fn func(data []byte) error) error {
+	s.replayLock.Lock()
+	defer s.replayLock.Unlock()
+
+	if s.Len() == 0 {
+		return nil
+	}
+
+	log.Printf("replaying %d spooled frames.\n", s.Len())
+
+	offset := int64(0)
+	for {
+		data, err := s.readAt(offset)
+		if err != nil {
+			return s.compactLocked(offset, err)
+		}
+		if data == nil {
+			break
+		}
+		if err := fn(data); err != nil {
+			return s.compactLocked(offset, err)
+		}
+		offset += spoolRecordHeaderSize + int64(len(data))
+
+		s.mu.Lock()
+		s.frames--
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+// readAt reads the frame at offset. When offset is at the end of the spool, the replayed frames are removed and
+// nil is returned.
+func (s *Spool) readAt(offset int64) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if offset >= s.size {
+		if err := s.file.Truncate(0); err != nil {
+			return nil, err
+		}
+		s.size = 0
+		s.frames = 0
+		return nil, nil
+	}
+
+	header := make([]byte, spoolRecordHeaderSize)
+	if _, err := s.file.ReadAt(header, offset); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint32(header))
+	if _, err := s.file.ReadAt(data, offset+spoolRecordHeaderSize); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// compactLocked calls compact with the spool locked.
+func (s *Spool) compactLocked(offset int64, cause error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.compact(offset, cause)
+}
+
+// compact removes the frames before offset from the spool file and returns cause.
+func (s *Spool) compact(offset int64, cause error) error {
+	if offset == 0 {
+		return cause
+	}
+
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("could not compact spool: %w", err)
+	}
+	if _, err := io.Copy(tmp, io.NewSectionReader(s.file, offset, s.size-offset)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not compact spool: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not compact spool: %w", err)
+	}
+	s.file.Close()
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		// Keep the original file; replayed frames will be replayed again.
+		tmp.Close()
+		if s.file, err = os.OpenFile(s.path, os.O_RDWR, 0600); err != nil {
+			return fmt.Errorf("could not reopen spool: %w", err)
+		}
+		s.frames = 0
+		return s.recover()
+	}
+	s.file = tmp
+	s.size -= offset
+
+	return cause
+}
+
+// Close syncs and closes the spool file. Spooled frames are kept on disk.
+func (s *Spool) Close() error {
+	close(s.closeChan)
+	<-s.syncLoopDone
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.sync(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
@@ -0,0 +1,152 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openTestSpool(t *testing.T, dir string, maxSize int64) *Spool {
+	s, err := OpenSpool(&SpoolOptions{Dir: dir, Name: "sat_1", MaxSize: maxSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSpoolReplayInOrder(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestSpool(t, dir, 1024)
+	for _, frame := range []string{"a", "bb", "ccc"} {
+		if err := s.Write([]byte(frame)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertEqual(t, s.Len(), 3, "")
+
+	// Frames survive a restart.
+	_ = s.Close()
+	s = openTestSpool(t, dir, 1024)
+	defer s.Close()
+	assertEqual(t, s.Len(), 3, "")
+
+	var replayed string
+	if err := s.Replay(func(data []byte) error {
+		replayed += string(data)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, replayed, "abbccc", "")
+	assertEqual(t, s.Len(), 0, "")
+}
+
+func TestSpoolPartialReplay(t *testing.T) {
+	s := openTestSpool(t, t.TempDir(), 1024)
+	defer s.Close()
+	for _, frame := range []string{"a", "b", "c"} {
+		_ = s.Write([]byte(frame))
+	}
+
+	consumerDown := errors.New("consumer down")
+	var replayed string
+	err := s.Replay(func(data []byte) error {
+		if string(data) == "b" {
+			return consumerDown
+		}
+		replayed += string(data)
+		return nil
+	})
+	assertEqual(t, err, consumerDown, "")
+	assertEqual(t, replayed, "a", "")
+	assertEqual(t, s.Len(), 2, "")
+
+	_ = s.Write([]byte("d"))
+	replayed = ""
+	_ = s.Replay(func(data []byte) error {
+		replayed += string(data)
+		return nil
+	})
+	assertEqual(t, replayed, "bcd", "")
+}
+
+func TestSpoolLimit(t *testing.T) {
+	s := openTestSpool(t, t.TempDir(), 2*(spoolRecordHeaderSize+4))
+	defer s.Close()
+
+	assertEqual(t, s.Write([]byte("1234")), nil, "")
+	assertEqual(t, s.Write([]byte("5678")), nil, "")
+	assertEqual(t, s.Write([]byte("9")), ErrSpoolFull, "")
+	assertEqual(t, s.Dropped(), uint64(1), "")
+	assertEqual(t, s.Len(), 2, "")
+}
+
+func TestSpoolDropsIncompleteFrame(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestSpool(t, dir, 1024)
+	_ = s.Write([]byte("complete"))
+	_ = s.Close()
+
+	// Simulate a crash in the middle of writing a frame.
+	f, err := os.OpenFile(filepath.Join(dir, "sat_1.spool"), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte{0, 0, 0, 10, 'x'})
+	_ = f.Close()
+
+	s = openTestSpool(t, dir, 1024)
+	defer s.Close()
+	assertEqual(t, s.Len(), 1, "")
+}
+
+func TestSpoolWriteDuringReplay(t *testing.T) {
+	s := openTestSpool(t, t.TempDir(), 1024)
+	defer s.Close()
+	_ = s.Write([]byte("a"))
+
+	var replayed string
+	if err := s.Replay(func(data []byte) error {
+		if string(data) == "a" {
+			// Writing while a frame is being replayed must not block.
+			if err := s.Write([]byte("b")); err != nil {
+				return err
+			}
+		}
+		replayed += string(data)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, replayed, "ab", "")
+	assertEqual(t, s.Len(), 0, "")
+}
+
+func TestSpoolName(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spool")
+	s, err := OpenSpool(&SpoolOptions{Dir: dir, Name: "../sat/1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// The name cannot put the spool file outside of its directory.
+	if _, err := os.Stat(filepath.Join(dir, ".._sat_1.spool")); err != nil {
+		t.Errorf("spool file not in the spool directory: %v", err)
+	}
+}
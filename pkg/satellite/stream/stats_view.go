@@ -0,0 +1,117 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"sync"
+	"time"
+)
+
+// StreamStats holds the live statistics of a stream and its current plan.
+type StreamStats struct {
+	SatelliteID string
+	PlanID      string
+	StreamID    string
+
+	TotalBytes     int64
+	TotalMessages  int64
+	RateBps        int64
+	AverageRateBps int64
+	Delay          time.Duration
+	AverageDelay   time.Duration
+	DelayP90       time.Duration
+	// Local time the last telemetry was received, zero if none was received for the plan.
+	LastReceived time.Time
+	Gaps         int
+	Reconnects   int64
+	// Estimated offset of the local clock, nil if it is not measured.
+	ClockOffset *time.Duration
+
+	ProxyClients    int64
+	CommandsSent    int64
+	CommandsFailed  int64
+	CommandsDropped int64
+	DroppedFrames   int64
+}
+
+// StatsView gives programs driving a stream, such as a dashboard, access to its live statistics.
+type StatsView struct {
+	mu          sync.Mutex
+	satelliteId string
+	collector   *MetricsCollector
+	counters    *StreamCounters
+	// counters of a stream without a metrics exporter
+	ownCounters StreamCounters
+}
+
+// NewStatsView creates a view to pass in SatelliteStreamOptions.
+func NewStatsView() *StatsView {
+	return &StatsView{}
+}
+
+// streamCounters returns counters for a stream without a metrics exporter, or nil if v is nil.
+func (v *StatsView) streamCounters() *StreamCounters {
+	if v == nil {
+		return nil
+	}
+	return &v.ownCounters
+}
+
+// show the statistics of collector and counters for the given satellite
+func (v *StatsView) setSource(satelliteId string, collector *MetricsCollector, counters *StreamCounters) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.satelliteId = satelliteId
+	v.collector = collector
+	v.counters = counters
+}
+
+// Stats returns the current statistics, which are empty until the stream is opened.
+func (v *StatsView) Stats() StreamStats {
+	v.mu.Lock()
+	satelliteId := v.satelliteId
+	collector := v.collector
+	counters := v.counters
+	v.mu.Unlock()
+
+	stats := StreamStats{SatelliteID: satelliteId}
+	if collector != nil {
+		s := collector.snapshot()
+		stats.PlanID = s.planId
+		stats.StreamID = s.streamId
+		stats.TotalBytes = s.totalBytes
+		stats.TotalMessages = s.totalMessages
+		stats.RateBps = s.instantRate
+		stats.AverageRateBps = s.avgRate
+		stats.Delay = time.Duration(s.instantDelay)
+		stats.AverageDelay = time.Duration(s.avgDelay)
+		stats.DelayP90 = time.Duration(s.delayP90)
+		stats.LastReceived = s.lastReceived
+		stats.Gaps = s.gaps
+		stats.Reconnects = s.reconnects
+		if s.clockOffset != nil {
+			offset := time.Duration(*s.clockOffset * float64(time.Second))
+			stats.ClockOffset = &offset
+		}
+	}
+	if counters != nil {
+		stats.ProxyClients = counters.proxyClients.Load()
+		stats.CommandsSent = counters.commandsSent.Load()
+		stats.CommandsFailed = counters.commandsFailed.Load()
+		stats.CommandsDropped = counters.commandsDropped.Load()
+		stats.DroppedFrames = counters.droppedFrames.Load()
+	}
+	return stats
+}
@@ -0,0 +1,87 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
+	"github.com/infostellarinc/stellarcli/pkg/auth"
+)
+
+func TestStatsView(t *testing.T) {
+	view := NewStatsView()
+	assertEqual(t, view.Stats(), StreamStats{}, "")
+
+	o := &SatelliteStreamOptions{StatsView: view}
+	counters := o.streamCounters()
+	counters.setProxyClients(2)
+	counters.addCommand(CommandFailed)
+
+	metrics := NewMetricsCollector(nil)
+	metrics.setPlanId("plan1")
+	metrics.setStreamId("stream1")
+	metrics.collectReconnect()
+	now := time.Now()
+	metrics.collectTelemetry("gs_1", createTelemetry(&now, 100))
+	view.setSource("sat1", metrics, counters)
+
+	stats := view.Stats()
+	assertEqual(t, stats.SatelliteID, "sat1", "")
+	assertEqual(t, stats.PlanID, "plan1", "")
+	assertEqual(t, stats.StreamID, "stream1", "")
+	assertEqual(t, stats.TotalBytes, int64(5), "")
+	assertEqual(t, stats.Reconnects, int64(1), "")
+	assertEqual(t, stats.ProxyClients, int64(2), "")
+	assertEqual(t, stats.CommandsFailed, int64(1), "")
+	assertEqual(t, stats.ClockOffset == nil, true, "")
+
+	// The metrics exporter's counters take precedence so that both see the same values.
+	exporter := &MetricsExporter{}
+	o.MetricsExporter = exporter
+	assertEqual(t, o.streamCounters(), &exporter.counters, "")
+}
+
+func TestStatsViewWithoutProxy(t *testing.T) {
+	// The stream cannot be opened without an API key, but the proxy still uses the counters of the view.
+	t.Setenv(auth.CredentialsEnv, filepath.Join(t.TempDir(), "missing.json"))
+
+	view := NewStatsView()
+	spool := openTestSpool(t, t.TempDir(), 1)
+	defer spool.Close()
+	proxy, err := NewConnectionWithoutProxy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := &SatelliteStreamOptions{
+		SatelliteID: "sat1",
+		StatsView:   view,
+		Spool:       spool,
+		Client:      apiclient.NewClientWithOptions(apiclient.Options{}),
+	}
+	if _, err := proxy.Start(o); err == nil {
+		t.Fatal("starting without an API key did not fail")
+	}
+	go proxy.(*noProxy).serve()
+	defer proxy.Close()
+
+	// The spool is full, so the frame is dropped.
+	failed := make(chan struct{})
+	proxy.(*noProxy).streamChan <- &Frame{Data: []byte("a"), fail: func() { close(failed) }}
+	<-failed
+	assertEqual(t, view.Stats().DroppedFrames, int64(1), "")
+}
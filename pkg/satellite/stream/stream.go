@@ -17,6 +17,8 @@ package stream
 import (
 	"bufio"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"sync"
@@ -24,7 +26,6 @@ import (
 	"time"
 
 	"github.com/cenkalti/backoff"
-	"google.golang.org/grpc"
 
 	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
 	"github.com/infostellarinc/stellarcli/pkg/apiclient"
@@ -39,8 +40,6 @@ const (
 
 const MaxElapsedTime = 60 * time.Second
 
-var metrics MetricsCollector
-
 type SatelliteStreamOptions struct {
 	AcceptedFraming []stellarstation.Framing
 	SatelliteID     string
@@ -51,15 +50,47 @@ type SatelliteStreamOptions struct {
 	IsVerbose       bool
 	ShowStats       bool
 	TelemetryFile   *os.File
+	EventSinks      []EventSink
+	Spool           *Spool
+	CommandQueue    *CommandQueueOptions
+	AuditLog        *CommandAuditLog
+	MetricsExporter *MetricsExporter
+	PassSummary     *PassSummaryOptions
+	// Minimum time without telemetry counted as a reception gap. Zero uses DefaultGapThreshold.
+	GapThreshold time.Duration
+	// NTP server, host or host:port, the local clock offset is measured with to correct the telemetry delay.
+	// Empty uses the local clock as is.
+	NTPServer string
+	Alerts    *AlertOptions
+	// Gives the caller access to the live statistics of the stream.
+	StatsView *StatsView
+	// Records every stream response for offline analysis.
+	Capture *CaptureWriter
+	// The API client the stream is opened with. The stream replaces its connection when it reconnects.
+	Client *apiclient.Client
 
 	CorrectOrder   bool
 	DelayThreshold time.Duration
 
 	EnableAutoClose bool
+	// Called once when the stream ends by itself: with nil when it is closed automatically, or with the error it ended
+	// with. When nil, the process exits instead.
+	OnEnd func(err error)
+}
+
+// streamCounters returns the counters updated by the stream and its proxy, or nil if nothing reads them.
+func (o *SatelliteStreamOptions) streamCounters() *StreamCounters {
+	if o.MetricsExporter != nil {
+		return o.MetricsExporter.streamCounters()
+	}
+	return o.StatsView.streamCounters()
 }
 
 type SatelliteStream interface {
 	Send(payload []byte) error
+	// SendFrom sends a packet on behalf of source, e.g. the address of a proxy client, which is recorded in the
+	// audit log.
+	SendFrom(source string, payload []byte) error
 
 	io.Closer
 }
@@ -70,21 +101,45 @@ type satelliteStream struct {
 	satelliteId     string
 	sendLock        sync.Mutex
 	stream          stellarstation.StellarStationService_OpenSatelliteStreamClient
-	conn            *grpc.ClientConn
+	client          *apiclient.Client
 	streamId        string
 	planId          string
 	groundStationId string
-
-	receiveChan           chan<- []byte
+	// ID of the plan last received. idLock guards it and streamId for readers outside the receive loop.
+	activePlanId string
+	idLock       sync.RWMutex
+	// Cancelled when the stream is closed, ending the stream call.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	receiveChan           chan<- *Frame
 	receiveLoopClosedChan chan struct{}
+	acks                  *ackTracker
 
 	telemetryFileWriter *bufio.Writer
+	fileLock            sync.Mutex
 
 	state         uint32
 	isDebug       bool
 	isVerbose     bool
 	showStats     bool
+	collectStats  bool
+	metrics       *MetricsCollector
 	telemetryFile *os.File
+	eventSinks    []EventSink
+	eventSinkLock sync.Mutex
+	commands      *commandQueue
+	auditLog      *CommandAuditLog
+	exporter      *MetricsExporter
+	counters      *StreamCounters
+	passSummary   *PassSummaryOptions
+	gapThreshold  time.Duration
+	ntpServer     string
+	clockOffset   *clockOffsetTracker
+	alertOptions  *AlertOptions
+	alerts        *alertMonitor
+	statsView     *StatsView
+	capture       *CaptureWriter
 
 	correctOrder   bool
 	delayThreshold time.Duration
@@ -92,37 +147,82 @@ type satelliteStream struct {
 	mu             sync.Mutex
 
 	enableAutoClose bool
+	onEnd           func(err error)
+	endOnce         sync.Once
 }
 
 // OpenSatelliteStream opens a stream to a satellite over the StellarStation API.
-func OpenSatelliteStream(o *SatelliteStreamOptions, receiveChan chan<- []byte) (SatelliteStream, func(), error) {
+// Received telemetry is handed to receiveChan and acknowledged to the API once every frame of a message has been
+// marked as delivered with Frame.Done.
+func OpenSatelliteStream(o *SatelliteStreamOptions, receiveChan chan<- *Frame) (SatelliteStream, func(), error) {
+	if o.Client == nil {
+		return nil, nil, errors.New("no API client to open the stream with")
+	}
+
 	satelliteStream := &satelliteStream{
 		acceptedFraming:       o.AcceptedFraming,
 		satelliteId:           o.SatelliteID,
 		streamId:              o.StreamId,
 		planId:                o.PlanId,
 		groundStationId:       o.GroundStationId,
+		client:                o.Client,
 		receiveChan:           receiveChan,
 		state:                 OPEN,
 		receiveLoopClosedChan: make(chan struct{}),
 		isDebug:               o.IsDebug,
 		isVerbose:             o.IsVerbose,
 		showStats:             o.ShowStats,
+		collectStats:          o.ShowStats || o.MetricsExporter != nil || o.PassSummary != nil || o.Alerts != nil || o.StatsView != nil,
 		telemetryFile:         o.TelemetryFile,
+		eventSinks:            o.EventSinks,
+		auditLog:              o.AuditLog,
+		exporter:              o.MetricsExporter,
+		counters:              o.streamCounters(),
+		passSummary:           o.PassSummary,
+		gapThreshold:          o.GapThreshold,
+		ntpServer:             o.NTPServer,
+		alertOptions:          o.Alerts,
+		statsView:             o.StatsView,
+		capture:               o.Capture,
 
 		correctOrder:   o.CorrectOrder,
 		delayThreshold: o.DelayThreshold,
 
 		enableAutoClose: o.EnableAutoClose,
+		onEnd:           o.OnEnd,
+	}
+	satelliteStream.acks = newAckTracker(satelliteStream.acknowledge)
+	if o.CommandQueue != nil {
+		satelliteStream.commands = newCommandQueue(o.CommandQueue, satelliteStream.sendCommand,
+			func(source string, payload []byte) {
+				satelliteStream.recordCommand(source, payload, CommandDropped, nil)
+			})
 	}
 
 	cleanup, err := satelliteStream.start()
+	if err != nil && satelliteStream.commands != nil {
+		// Nothing can be sent on a stream that could not be opened.
+		satelliteStream.commands.close()
+	}
 
 	return satelliteStream, cleanup, err
 }
 
-// Send sends a packet to the satellite.
+// Send sends a packet to the satellite. When a command queue is configured, the packet is queued and sent at the
+// configured rate; ErrCommandQueueFull is returned if the queue is full.
 func (ss *satelliteStream) Send(payload []byte) error {
+	return ss.SendFrom("", payload)
+}
+
+// SendFrom sends a packet to the satellite like Send, recording source in the audit log.
+func (ss *satelliteStream) SendFrom(source string, payload []byte) error {
+	if ss.commands != nil {
+		return ss.commands.enqueue(source, payload)
+	}
+	return ss.sendCommand(source, payload)
+}
+
+func (ss *satelliteStream) sendCommand(source string, payload []byte) error {
 	satelliteStreamRequest := stellarstation.SatelliteStreamRequest{
 		SatelliteId: ss.satelliteId,
 		Request: &stellarstation.SatelliteStreamRequest_SendSatelliteCommandsRequest{
@@ -135,25 +235,87 @@ func (ss *satelliteStream) Send(payload []byte) error {
 	log.Verbose("sent data: size: %d bytes\n", len(payload))
 
 	ss.sendLock.Lock()
-	defer ss.sendLock.Unlock()
-	return ss.stream.Send(&satelliteStreamRequest)
+	err := ss.stream.Send(&satelliteStreamRequest)
+	ss.sendLock.Unlock()
+
+	ss.recordCommand(source, payload, "", err)
+	return err
+}
+
+// count a command by its outcome and record it in the audit log, if any
+func (ss *satelliteStream) recordCommand(source string, payload []byte, outcome string, sendErr error) {
+	if outcome == "" {
+		outcome = CommandSent
+		if sendErr != nil {
+			outcome = CommandFailed
+		}
+	}
+	ss.counters.addCommand(outcome)
+
+	if ss.auditLog == nil {
+		return
+	}
+
+	planId, streamId := ss.currentIds()
+	record := &CommandAuditRecord{
+		SatelliteID: ss.satelliteId,
+		PlanID:      planId,
+		StreamID:    streamId,
+		Source:      source,
+		Outcome:     outcome,
+	}
+
+	if err := ss.auditLog.Record(record, payload, sendErr); err != nil {
+		log.Printf("could not write audit log: %v\n", err)
+	}
+}
+
+// currentIds returns the ID of the plan last received, or the plan requested if none was, and the stream ID.
+func (ss *satelliteStream) currentIds() (string, string) {
+	ss.idLock.RLock()
+	defer ss.idLock.RUnlock()
+	planId := ss.activePlanId
+	if planId == "" {
+		planId = ss.planId
+	}
+	return planId, ss.streamId
 }
 
 // Close closes the stream.
 func (ss *satelliteStream) Close() error {
 	atomic.StoreUint32(&ss.state, CLOSED)
 
-	_ = ss.stream.CloseSend()
-	ss.conn.Close()
+	if ss.commands != nil {
+		ss.commands.close()
+	}
+
+	// The stream and its receive loop only exist if the stream could be opened.
+	ss.sendLock.Lock()
+	stream := ss.stream
+	if stream != nil {
+		_ = stream.CloseSend()
+	}
+	ss.sendLock.Unlock()
+	if stream != nil {
+		ss.cancel()
 
-	<-ss.receiveLoopClosedChan
+		<-ss.receiveLoopClosedChan
+	}
 
+	if ss.metrics != nil {
+		ss.metrics.StopStatsEmitScheduler()
+	}
+	ss.clockOffset.stop()
+	ss.alerts.stop()
 	_ = ss.CloseFileWriter()
+	ss.closeEventSinks()
 
 	return nil
 }
 
 func (ss *satelliteStream) CloseFileWriter() error {
+	ss.fileLock.Lock()
+	defer ss.fileLock.Unlock()
 	if ss.telemetryFileWriter != nil {
 		ss.telemetryFileWriter.Flush()
 		ss.telemetryFileWriter = nil
@@ -166,6 +328,61 @@ func (ss *satelliteStream) CloseFileWriter() error {
 	return nil
 }
 
+func (ss *satelliteStream) closeEventSinks() {
+	ss.eventSinkLock.Lock()
+	defer ss.eventSinkLock.Unlock()
+	for _, sink := range ss.eventSinks {
+		_ = sink.Close()
+	}
+	ss.eventSinks = nil
+}
+
+// deliver a monitoring event to every configured event sink
+func (ss *satelliteStream) publishEvent(e *MonitoringEvent) {
+	ss.eventSinkLock.Lock()
+	defer ss.eventSinkLock.Unlock()
+	for _, sink := range ss.eventSinks {
+		if err := sink.Send(e); err != nil {
+			log.Debug("could not send monitoring event: %v\n", err)
+		}
+	}
+}
+
+// write telemetry data to the output file, if any
+func (ss *satelliteStream) writeTelemetryFile(data []byte) {
+	ss.fileLock.Lock()
+	defer ss.fileLock.Unlock()
+	if ss.telemetryFileWriter != nil {
+		if _, err := ss.telemetryFileWriter.Write(data); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// flush buffered telemetry data to the output file, if any
+func (ss *satelliteStream) flushTelemetryFile() {
+	ss.fileLock.Lock()
+	defer ss.fileLock.Unlock()
+	if ss.telemetryFileWriter != nil {
+		if err := ss.telemetryFileWriter.Flush(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// deliver hands a frame to the proxy after writing it to the output file. The frame is acknowledged once the proxy
+// has delivered it and the output file has been flushed.
+func (ss *satelliteStream) deliver(group *ackGroup, data []byte) {
+	ss.writeTelemetryFile(data)
+	ss.receiveChan <- ss.acks.frame(group, data)
+}
+
+// acknowledge a fully delivered message
+func (ss *satelliteStream) acknowledge(telemetryMessageAckId string) {
+	ss.flushTelemetryFile()
+	ss.ackReceivedTelemetry(telemetryMessageAckId)
+}
+
 // send telemetryMessageAckId to support enableFlowControl feature
 func (ss *satelliteStream) ackReceivedTelemetry(telemetryMessageAckId string) {
 	if telemetryMessageAckId != "" {
@@ -185,25 +402,62 @@ func (ss *satelliteStream) ackReceivedTelemetry(telemetryMessageAckId string) {
 	}
 }
 
+// remember the ID of the plan the stream is currently receiving for
+func (ss *satelliteStream) setActivePlanId(planId string) {
+	if planId == "" {
+		return
+	}
+	ss.idLock.Lock()
+	defer ss.idLock.Unlock()
+	ss.activePlanId = planId
+}
+
+// telemetry waiting in the sorting pool
+type queuedTelemetry struct {
+	telemetry *stellarstation.Telemetry
+	group     *ackGroup
+}
+
 func (ss *satelliteStream) performAutoClose() {
 	log.Printf("Stream auto-close conditions met - exiting")
-	if ss.showStats {
-		metrics.logReport()
+	if ss.onEnd != nil {
+		ss.end(nil)
+		return
+	}
+	if ss.metrics != nil {
+		ss.metrics.StopStatsEmitScheduler()
+		ss.metrics.reportPass()
 	}
+	ss.clockOffset.stop()
+	ss.alerts.stop()
 	ss.Close()
 	os.Exit(0)
 }
 
+// end reports that the stream ended by itself, with nil when it was closed automatically, or exits if nothing waits
+// for it.
+func (ss *satelliteStream) end(err error) {
+	ss.endOnce.Do(func() {
+		if ss.onEnd != nil {
+			ss.onEnd(err)
+			return
+		}
+		log.Fatalf("%v\n", err)
+	})
+}
+
 func (ss *satelliteStream) receiveLoop() {
 	streamEndDetected := false
 
 	if ss.enableAutoClose {
 		ticker := time.NewTicker(1 * time.Second)
 		go func() {
+			defer ticker.Stop()
 			for {
 				<-ticker.C
 				if streamEndDetected {
 					ss.performAutoClose()
+					return
 				}
 			}
 		}()
@@ -219,9 +473,9 @@ func (ss *satelliteStream) receiveLoop() {
 	}
 	telemetryMessageAckId := ""
 
-	pq := collection.NewPriorityQueue((*stellarstation.Telemetry)(nil), func(i, j interface{}) bool {
-		telemetry1 := i.(*stellarstation.Telemetry)
-		telemetry2 := j.(*stellarstation.Telemetry)
+	pq := collection.NewPriorityQueue((*queuedTelemetry)(nil), func(i, j interface{}) bool {
+		telemetry1 := i.(*queuedTelemetry).telemetry
+		telemetry2 := j.(*queuedTelemetry).telemetry
 
 		time1 := telemetry1.GetTimeFirstByteReceived().AsTime()
 		time2 := telemetry2.GetTimeFirstByteReceived().AsTime()
@@ -241,13 +495,9 @@ func (ss *satelliteStream) receiveLoop() {
 
 		// Flush half of the data in the priority queue
 		for i := 0; i < numFlush; i++ {
-			telemetry := pq.Pop().(*stellarstation.Telemetry)
-			ss.receiveChan <- telemetry.Data
-			if ss.telemetryFileWriter != nil {
-				if _, err := ss.telemetryFileWriter.Write(telemetry.Data); err != nil {
-					panic(err)
-				}
-			}
+			queued := pq.Pop().(*queuedTelemetry)
+			ss.deliver(queued.group, queued.telemetry.Data)
+			ss.acks.done(queued.group)
 		}
 		ss.flushTimer.Reset(ss.delayThreshold)
 	})
@@ -264,7 +514,7 @@ func (ss *satelliteStream) receiveLoop() {
 			log.Println("reconnecting to the API stream.")
 
 			reconnectError := backoff.RetryNotify(func() error {
-				err := ss.openStream(telemetryMessageAckId)
+				err := ss.openStream(telemetryMessageAckId, true)
 				if err != nil {
 					return err
 				}
@@ -285,19 +535,32 @@ func (ss *satelliteStream) receiveLoop() {
 				// (or higher-level) cleanup function.
 				_ = ss.CloseFileWriter()
 				// Couldn't reconnect to the server, bailout.
-				log.Fatalf("error connecting to API stream: %v\n", err)
+				ss.end(fmt.Errorf("error connecting to API stream: %w", reconnectError))
+				close(ss.receiveLoopClosedChan)
+				return
 			}
 			log.Println("connected to the API stream.")
+			ss.counters.addReconnect()
+			if ss.metrics != nil {
+				ss.metrics.collectReconnect()
+			}
 		}
 		if streamResponse == nil {
 			continue
 		}
+		if ss.capture != nil {
+			if err := ss.capture.Write(time.Now(), streamResponse); err != nil {
+				log.Printf("could not write capture file: %v\n", err)
+			}
+		}
 		if ss.streamId != streamResponse.StreamId {
 			log.Printf("streamId: %v\n", streamResponse.StreamId)
 		}
+		ss.idLock.Lock()
 		ss.streamId = streamResponse.StreamId
-		if ss.showStats {
-			metrics.setStreamId(ss.streamId)
+		ss.idLock.Unlock()
+		if ss.metrics != nil {
+			ss.metrics.setStreamId(ss.streamId)
 		}
 
 		switch streamResponse.Response.(type) {
@@ -307,9 +570,11 @@ func (ss *satelliteStream) receiveLoop() {
 				break
 			}
 			planId := telemetryResponse.PlanId
-			if ss.showStats {
-				metrics.setPlanId(planId)
+			ss.setActivePlanId(planId)
+			if ss.metrics != nil {
+				ss.metrics.setPlanId(planId)
 			}
+			group := ss.acks.begin(telemetryResponse.MessageAckId)
 			for _, telemetry := range telemetryResponse.Telemetry {
 				if telemetry == nil {
 					break
@@ -318,27 +583,25 @@ func (ss *satelliteStream) receiveLoop() {
 				telemetry := telemetry
 				telemetryData := telemetry.Data
 				log.Debug("received data: streamId: %v, planId: %s, groundStationId: %s, framing type: %s, size: %d bytes\n", ss.streamId, planId, telemetryResponse.GroundStationId, telemetry.Framing, len(telemetryData))
-				if ss.showStats {
-					metrics.collectTelemetry(telemetry)
+				if ss.metrics != nil {
+					ss.metrics.collectTelemetry(telemetryResponse.GroundStationId, telemetry)
 				}
 				if ss.correctOrder {
+					// hold a reference to the group until the telemetry leaves the queue
+					ss.acks.add(group)
 					go func() {
 						ss.mu.Lock()
 						defer ss.mu.Unlock()
-						pq.Push(telemetry)
+						pq.Push(&queuedTelemetry{telemetry: telemetry, group: group})
 					}()
 				} else {
-					ss.receiveChan <- telemetryData
-					if ss.telemetryFileWriter != nil {
-						if _, err := ss.telemetryFileWriter.Write(telemetryData); err != nil {
-							panic(err)
-						}
-					}
+					ss.deliver(group, telemetryData)
 				}
 			}
-			// Send ack & update telemetryMessageAckId in case we need to resume from disconnects
+			// Update telemetryMessageAckId in case we need to resume from disconnects; the ack itself is sent once
+			// all telemetry of the message has been delivered.
 			telemetryMessageAckId = telemetryResponse.MessageAckId
-			ss.ackReceivedTelemetry(telemetryResponse.MessageAckId)
+			ss.acks.done(group)
 
 			// A telemetryResponse containing one telemetry message with a size of zero indicates the stream END message.
 			if ss.enableAutoClose && len(telemetryResponse.Telemetry) == 1 && len(telemetryResponse.Telemetry[0].Data) == 0 {
@@ -351,6 +614,7 @@ func (ss *satelliteStream) receiveLoop() {
 			streamEvent := streamResponse.GetStreamEvent()
 			monitoringEvent := streamEvent.GetPlanMonitoringEvent()
 			planId := monitoringEvent.PlanId
+			ss.setActivePlanId(planId)
 
 			if ss.isVerbose {
 				if gsState := monitoringEvent.GetGroundStationState(); gsState != nil {
@@ -363,21 +627,30 @@ func (ss *satelliteStream) receiveLoop() {
 					}
 				}
 			}
+
+			if e := newMonitoringEvent(ss.satelliteId, ss.streamId, monitoringEvent); e != nil {
+				ss.publishEvent(e)
+			}
 		}
 	}
 }
 
-func (ss *satelliteStream) openStream(resumeStreamMessageAckId string) error {
-	conn, err := apiclient.Dial()
+// Open the stream on the connection of the client. When reconnecting, the connection is replaced first, in case the
+// old one is broken.
+func (ss *satelliteStream) openStream(resumeStreamMessageAckId string, reconnect bool) error {
+	connect := ss.client.Conn
+	if reconnect {
+		connect = ss.client.Reconnect
+	}
+	conn, err := connect()
 	if err != nil {
 		return err
 	}
 
 	client := stellarstation.NewStellarStationServiceClient(conn)
 
-	stream, err := client.OpenSatelliteStream(context.Background())
+	stream, err := client.OpenSatelliteStream(ss.ctx)
 	if err != nil {
-		conn.Close()
 		return err
 	}
 
@@ -397,16 +670,17 @@ func (ss *satelliteStream) openStream(resumeStreamMessageAckId string) error {
 		satelliteStreamRequest.GroundStationId = ss.groundStationId
 	}
 
+	// Acknowledgements and commands are sent from other goroutines: the stream is replaced under sendLock.
 	ss.sendLock.Lock()
 	err = stream.Send(&satelliteStreamRequest)
+	if err == nil {
+		ss.stream = stream
+	}
 	ss.sendLock.Unlock()
 	if err != nil {
-		conn.Close()
 		return err
 	}
 
-	ss.conn = conn
-	ss.stream = stream
 	if ss.streamId != "" {
 		log.Verbose("streamId: %v\n", ss.streamId)
 	}
@@ -421,26 +695,59 @@ func (ss *satelliteStream) start() (func(), error) {
 	// metric collector for data rate, total received size, etc
 	if ss.showStats {
 		if ss.isVerbose || ss.isDebug {
-			metrics = *NewMetricsCollector(log.PrintfRawLn)
-			metrics.StartStatsEmitScheduler(2000)
+			ss.metrics = NewMetricsCollector(log.PrintfRawLn)
+			ss.metrics.StartStatsEmitScheduler(2000)
+		} else {
+			ss.metrics = NewMetricsCollector(log.LastLine)
+			ss.metrics.StartStatsEmitScheduler(500)
+		}
+	} else if ss.collectStats {
+		ss.metrics = NewMetricsCollector(nil)
+	}
+	if ss.metrics != nil && ss.gapThreshold > 0 {
+		ss.metrics.setGapThreshold(ss.gapThreshold)
+	}
+	if ss.passSummary != nil {
+		ss.metrics.setSummaryOptions(ss.satelliteId, ss.passSummary)
+	}
+	if ss.exporter != nil {
+		ss.exporter.setCollector(ss.satelliteId, ss.metrics)
+	}
+	if ss.statsView != nil {
+		ss.statsView.setSource(ss.satelliteId, ss.metrics, ss.counters)
+	}
+	if ss.metrics != nil && ss.ntpServer != "" {
+		ss.clockOffset = startClockOffsetTracker(ss.ntpServer, ss.metrics)
+	}
+	if ss.alertOptions != nil {
+		ss.alerts = startAlertMonitor(ss.alertOptions, ss.satelliteId, ss.metrics, ss.currentIds)
+	}
+	if ss.showStats {
+		if ss.clockOffset != nil {
+			ss.metrics.logger("[STATS] correcting telemetry delay for the local clock offset measured with %s", ss.ntpServer)
 		} else {
-			metrics = *NewMetricsCollector(log.LastLine)
-			metrics.StartStatsEmitScheduler(500)
+			ss.metrics.logger("[STATS] using local time to calculate telemetry delay")
 		}
 	}
 
-	err := ss.openStream("")
+	ss.ctx, ss.cancel = context.WithCancel(context.Background())
+	err := ss.openStream("", false)
 	if err != nil {
+		ss.cancel()
 		return nil, err
 	}
 	go ss.receiveLoop()
 
 	// return a cleanup function to exec on exit
 	cleanup := func() {
-		if ss.showStats {
-			metrics.logReport()
+		if ss.metrics != nil {
+			ss.metrics.StopStatsEmitScheduler()
+			ss.metrics.reportPass()
 		}
+		ss.clockOffset.stop()
+		ss.alerts.stop()
 		_ = ss.CloseFileWriter()
+		ss.closeEventSinks()
 	}
 	return cleanup, nil
 }
@@ -27,10 +27,25 @@ type tcpProxy struct {
 	listener     net.Listener
 	connected    chan net.Conn
 	disconnected chan net.Conn
+	replayed     chan *spoolReplay
 
 	stream      SatelliteStream
-	streamChan  chan []byte
-	commandChan chan []byte
+	streamChan  chan *Frame
+	commandChan chan *proxyCommand
+	spool       *Spool
+	counters    *StreamCounters
+}
+
+// The outcome of replaying the spool to a client.
+type spoolReplay struct {
+	conn net.Conn
+	err  error
+}
+
+// A command read from a client, with the client's address as its source.
+type proxyCommand struct {
+	source  string
+	payload []byte
 }
 
 type TCPProxyOptions struct {
@@ -41,15 +56,16 @@ type TCPProxyOptions struct {
 func NewTCPProxy(o *TCPProxyOptions) (Proxy, error) {
 	listener, err := net.Listen("tcp", o.Addr)
 	if err != nil {
-		log.Fatalf("cannot listen: %v:", err)
+		return nil, err
 	}
 
 	p := &tcpProxy{
 		listener:     listener,
 		connected:    make(chan net.Conn),
 		disconnected: make(chan net.Conn),
-		streamChan:   make(chan []byte),
-		commandChan:  make(chan []byte),
+		replayed:     make(chan *spoolReplay),
+		streamChan:   make(chan *Frame),
+		commandChan:  make(chan *proxyCommand),
 	}
 
 	return p, nil
@@ -59,9 +75,11 @@ func NewTCPProxy(o *TCPProxyOptions) (Proxy, error) {
 func (p *tcpProxy) Start(o *SatelliteStreamOptions) (func(), error) {
 	var err error
 	var cleanup func()
+	p.spool = o.Spool
+	p.counters = o.streamCounters()
 	p.stream, cleanup, err = OpenSatelliteStream(o, p.streamChan)
 	if err != nil {
-		log.Fatalf("failed to connect to StellarStation: %v:", err)
+		return cleanup, err
 	}
 
 	go p.serve()
@@ -87,34 +105,104 @@ func (p *tcpProxy) Start(o *SatelliteStreamOptions) (func(), error) {
 func (p *tcpProxy) Close() error {
 	p.listener.Close()
 
+	// Close the API stream, if the proxy was started.
+	if p.stream != nil {
+		p.stream.Close()
+	}
+
 	return nil
 }
 
-// Sends packets received from Satellite to all clients.
+// Sends packets received from Satellite to all clients. A client connecting while frames are spooled first receives
+// the spooled frames; frames received meanwhile are spooled behind them until the replay has drained the spool.
 func (p *tcpProxy) serve() {
 	conns := make(map[net.Conn]bool)
+	// The client the spool is being replayed to, if any.
+	var replaying net.Conn
+
+	setClients := func() {
+		n := len(conns)
+		if replaying != nil {
+			n++
+		}
+		log.Println("connected clients:", n)
+		p.counters.setProxyClients(n)
+	}
 
 	for {
 		select {
 		case conn := <-p.connected:
-			conns[conn] = true
 			log.Println("connected to a new client:", conn.RemoteAddr().String())
-			log.Println("connected clients:", len(conns))
+			if p.spool != nil && replaying == nil && p.spool.Len() > 0 {
+				replaying = conn
+				go p.replaySpool(conn)
+			} else {
+				conns[conn] = true
+			}
+			setClients()
+		case r := <-p.replayed:
+			if r.conn != replaying {
+				// The client disconnected during the replay.
+				break
+			}
+			if r.err != nil {
+				log.Printf("could not replay spooled frames: %v\n", r.err)
+				replaying = nil
+				setClients()
+			} else if p.spool.Len() > 0 {
+				// Frames spooled after the replay drained the spool.
+				go p.replaySpool(r.conn)
+			} else {
+				conns[r.conn] = true
+				replaying = nil
+			}
 		case conn := <-p.disconnected:
 			delete(conns, conn)
+			if conn == replaying {
+				replaying = nil
+			}
 			conn.Close()
 			log.Println("disconnected the client:", conn.RemoteAddr().String())
-			log.Println("connected clients:", len(conns))
-		case payload := <-p.streamChan:
+			setClients()
+		case frame := <-p.streamChan:
+			// The frame is acknowledged once a client received it or it has been spooled.
+			delivered := false
 			for conn := range conns {
-				_, _ = conn.Write(payload)
+				if _, err := conn.Write(frame.Data); err != nil {
+					log.PrintlnThrottled("could not send frame to %v: %v", conn.RemoteAddr(), err)
+					continue
+				}
+				delivered = true
+			}
+			if p.spool != nil && (replaying != nil || !delivered) {
+				if err := p.spool.Write(frame.Data); err != nil {
+					log.PrintlnThrottled("could not spool frame: %v", err)
+				} else {
+					delivered = true
+				}
+			}
+			if delivered {
+				frame.Done()
+			} else {
+				p.counters.addDroppedFrame()
+				frame.Fail()
 			}
 		case command := <-p.commandChan:
-			_ = p.stream.Send(command)
+			_ = p.stream.SendFrom(command.source, command.payload)
 		}
 	}
 
 }
+
+// Replay the spool to a client and report the outcome to serve.
+func (p *tcpProxy) replaySpool(conn net.Conn) {
+	err := p.spool.Replay(func(data []byte) error {
+		_, err := conn.Write(data)
+		return err
+	})
+	p.replayed <- &spoolReplay{conn: conn, err: err}
+}
+
 func (p *tcpProxy) handleConn(conn net.Conn) {
 	p.connected <- conn
 
@@ -135,7 +223,10 @@ func (p *tcpProxy) handleConn(conn net.Conn) {
 			}
 			// Pass through timeout error.
 		} else {
-			p.commandChan <- buf[:n]
+			p.commandChan <- &proxyCommand{
+				source:  "tcp:" + conn.RemoteAddr().String(),
+				payload: buf[:n],
+			}
 		}
 	}
 }
@@ -0,0 +1,128 @@
+// Copyright © 2024 Infostellar, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"io"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/infostellarinc/stellarcli/pkg/apiclient"
+	"github.com/infostellarinc/stellarcli/pkg/auth"
+)
+
+func TestTCPProxyReplaysSpoolBeforeNewFrames(t *testing.T) {
+	spool := openTestSpool(t, t.TempDir(), 1024)
+	defer spool.Close()
+	for _, frame := range []string{"a", "b"} {
+		_ = spool.Write([]byte(frame))
+	}
+
+	proxy, err := NewTCPProxy(&TCPProxyOptions{Addr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Close()
+	p := proxy.(*tcpProxy)
+	p.spool = spool
+	go p.serve()
+	go func() {
+		conn, err := p.listener.Accept()
+		if err == nil {
+			p.handleConn(conn)
+		}
+	}()
+
+	client, err := net.Dial("tcp", p.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var delivered atomic.Int32
+	for _, frame := range []string{"c", "d"} {
+		p.streamChan <- &Frame{Data: []byte(frame), done: func() { delivered.Add(1) }}
+	}
+
+	_ = client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	received := make([]byte, 4)
+	if _, err := io.ReadFull(client, received); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, string(received), "abcd", "")
+	assertEqual(t, delivered.Load(), int32(2), "")
+	assertEqual(t, spool.Len(), 0, "")
+}
+
+func TestTCPProxyFailsUndeliveredFrame(t *testing.T) {
+	proxy, err := NewTCPProxy(&TCPProxyOptions{Addr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Close()
+	p := proxy.(*tcpProxy)
+	go p.serve()
+
+	failed := make(chan struct{})
+	p.streamChan <- &Frame{
+		Data: []byte("a"),
+		done: func() { t.Error("frame without a client was acknowledged") },
+		fail: func() { close(failed) },
+	}
+	select {
+	case <-failed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("frame was not failed")
+	}
+}
+
+func TestTCPProxyListenError(t *testing.T) {
+	proxy, err := NewTCPProxy(&TCPProxyOptions{Addr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Close()
+
+	_, err = NewTCPProxy(&TCPProxyOptions{Addr: proxy.(*tcpProxy).listener.Addr().String()})
+	if err == nil {
+		t.Fatal("listening on an address in use did not fail")
+	}
+}
+
+func TestTCPProxyStartError(t *testing.T) {
+	// The stream cannot be opened without an API key.
+	t.Setenv(auth.CredentialsEnv, filepath.Join(t.TempDir(), "missing.json"))
+
+	proxy, err := NewTCPProxy(&TCPProxyOptions{Addr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := &SatelliteStreamOptions{
+		SatelliteID:  "1",
+		CommandQueue: &CommandQueueOptions{MaxDepth: 1},
+		Client:       apiclient.NewClientWithOptions(apiclient.Options{}),
+	}
+	if _, err := proxy.Start(o); err == nil {
+		t.Fatal("starting without an API key did not fail")
+	}
+
+	// Closing a proxy whose stream could not be opened must not block or panic.
+	if err := proxy.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
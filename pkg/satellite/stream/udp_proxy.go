@@ -29,7 +29,9 @@ type udpProxy struct {
 	sendCloseChan chan struct{}
 
 	stream     SatelliteStream
-	streamChan chan []byte
+	streamChan chan *Frame
+	spool      *Spool
+	counters   *StreamCounters
 
 	closeWg sync.WaitGroup
 }
@@ -60,7 +62,7 @@ func NewUDPProxy(o *UDPProxyOptions) (Proxy, error) {
 		return nil, err
 	}
 
-	streamChan := make(chan []byte)
+	streamChan := make(chan *Frame)
 
 	p := &udpProxy{
 		recvConn:      rc,
@@ -79,6 +81,8 @@ func (p *udpProxy) Start(o *SatelliteStreamOptions) (func(), error) {
 
 	var err error
 	var cleanup func()
+	p.spool = o.Spool
+	p.counters = o.streamCounters()
 	p.stream, cleanup, err = OpenSatelliteStream(o, p.streamChan)
 	if err != nil {
 		return cleanup, err
@@ -118,25 +122,73 @@ func (p *udpProxy) recvLoop() {
 			return
 		default:
 			_ = p.recvConn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
-			n, _, err := p.recvConn.ReadFrom(recvBuf)
+			n, addr, err := p.recvConn.ReadFrom(recvBuf)
 			if err != nil {
 				if !err.(net.Error).Timeout() {
 					log.Printf("error receiving on UDP port: %v\n", err)
 					return
 				}
 			} else {
-				_ = p.stream.Send(recvBuf[:n])
+				_ = p.stream.SendFrom("udp:"+addr.String(), recvBuf[:n])
 			}
 		}
 	}
 }
 
+// Send a payload to the UDP destination. When a spool is configured, payloads that cannot be sent are spooled, and
+// new payloads are queued behind spooled ones to keep them in order. Returns false if the payload was dropped.
+func (p *udpProxy) send(payload []byte) bool {
+	if p.spool == nil {
+		if _, err := p.sendConn.Write(payload); err != nil {
+			p.counters.addDroppedFrame()
+			return false
+		}
+		return true
+	}
+
+	if p.replaySpool() {
+		if _, err := p.sendConn.Write(payload); err == nil {
+			return true
+		}
+		log.Println("UDP destination unavailable, spooling frames.")
+	}
+	if err := p.spool.Write(payload); err != nil {
+		log.PrintlnThrottled("could not spool frame: %v", err)
+		p.counters.addDroppedFrame()
+		return false
+	}
+	return true
+}
+
+// Replay spooled payloads to the UDP destination. Returns true when the spool is empty.
+func (p *udpProxy) replaySpool() bool {
+	if p.spool == nil || p.spool.Len() == 0 {
+		return true
+	}
+
+	err := p.spool.Replay(func(data []byte) error {
+		_, err := p.sendConn.Write(data)
+		return err
+	})
+	return err == nil
+}
+
 func (p *udpProxy) sendLoop() {
 	defer p.closeWg.Done()
+
+	replayTicker := time.NewTicker(time.Second)
+	defer replayTicker.Stop()
+
 	for {
 		select {
-		case payload := <-p.streamChan:
-			_, _ = p.sendConn.Write(payload)
+		case frame := <-p.streamChan:
+			if p.send(frame.Data) {
+				frame.Done()
+			} else {
+				frame.Fail()
+			}
+		case <-replayTicker.C:
+			p.replaySpool()
 		case <-p.sendCloseChan:
 			p.sendConn.Close()
 			return
@@ -16,11 +16,11 @@ package tle
 
 import (
 	"context"
+	"fmt"
 
 	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
 	"github.com/infostellarinc/go-stellarstation/api/v1/orbit"
 	"github.com/infostellarinc/stellarcli/pkg/apiclient"
-	log "github.com/infostellarinc/stellarcli/pkg/logger"
 	"github.com/infostellarinc/stellarcli/util/printer"
 )
 
@@ -32,14 +32,12 @@ type AddTLEOptions struct {
 }
 
 // AddTLE adds a new TLE to a given satellite.
-func AddTLE(o *AddTLEOptions) {
-	conn, err := apiclient.Dial()
+func AddTLE(apiClient *apiclient.Client, o *AddTLEOptions) error {
+	client, err := apiClient.Service()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer conn.Close()
 
-	client := stellarstation.NewStellarStationServiceClient(conn)
 	tle := &orbit.Tle{
 		Line_1: o.Line1,
 		Line_2: o.Line2,
@@ -51,11 +49,12 @@ func AddTLE(o *AddTLEOptions) {
 
 	_, err = client.AddTle(context.Background(), request)
 	if err != nil {
-		log.Printf("problem adding TLE: %v\n", err)
-		return
+		return fmt.Errorf("problem adding TLE: %w", err)
 	}
 
 	defer o.Printer.Flush()
 	message := "Succeeded to add the TLE."
 	o.Printer.Write([]interface{}{message})
+
+	return nil
 }
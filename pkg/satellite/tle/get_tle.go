@@ -16,10 +16,10 @@ package tle
 
 import (
 	"context"
+	"fmt"
 
 	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
 	"github.com/infostellarinc/stellarcli/pkg/apiclient"
-	log "github.com/infostellarinc/stellarcli/pkg/logger"
 	"github.com/infostellarinc/stellarcli/util/printer"
 )
 
@@ -35,20 +35,17 @@ type GetTLEOptions struct {
 }
 
 // GetTLE returns a TLE for the given satellite.
-func GetTLE(o *GetTLEOptions) {
-	conn, err := apiclient.Dial()
+func GetTLE(apiClient *apiclient.Client, o *GetTLEOptions) error {
+	client, err := apiClient.Service()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer conn.Close()
 
-	client := stellarstation.NewStellarStationServiceClient(conn)
 	request := &stellarstation.GetTleRequest{SatelliteId: o.SatelliteId}
 
 	result, err := client.GetTle(context.Background(), request)
 	if err != nil {
-		log.Printf("problem getting TLE: %v\n", err)
-		return
+		return fmt.Errorf("problem getting TLE: %w", err)
 	}
 
 	defer o.Printer.Flush()
@@ -59,4 +56,6 @@ func GetTLE(o *GetTLEOptions) {
 		result.Tle.Line_2,
 	}
 	o.Printer.Write(record)
+
+	return nil
 }
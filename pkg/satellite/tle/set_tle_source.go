@@ -16,11 +16,11 @@ package tle
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	stellarstation "github.com/infostellarinc/go-stellarstation/api/v1"
 	"github.com/infostellarinc/stellarcli/pkg/apiclient"
-	log "github.com/infostellarinc/stellarcli/pkg/logger"
 	"github.com/infostellarinc/stellarcli/util/printer"
 )
 
@@ -31,14 +31,11 @@ type SetTLESourceOptions struct {
 }
 
 // SetTleSource set the TLE source for a given satellite.
-func SetTLESource(o *SetTLESourceOptions) {
-	conn, err := apiclient.Dial()
+func SetTLESource(apiClient *apiclient.Client, o *SetTLESourceOptions) error {
+	client, err := apiClient.Service()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer conn.Close()
-
-	client := stellarstation.NewStellarStationServiceClient(conn)
 
 	var sourceOption = strings.ToLower(o.Source)
 	var source stellarstation.SetTleSourceRequest_Source
@@ -48,8 +45,7 @@ func SetTLESource(o *SetTLESourceOptions) {
 	} else if sourceOption == "norad" {
 		source = stellarstation.SetTleSourceRequest_NORAD
 	} else {
-		log.Printf("invalid source provided: '%v'\n", sourceOption)
-		return
+		return fmt.Errorf("invalid source provided: '%v'", sourceOption)
 	}
 
 	request := &stellarstation.SetTleSourceRequest{
@@ -59,11 +55,12 @@ func SetTLESource(o *SetTLESourceOptions) {
 
 	_, err = client.SetTleSource(context.Background(), request)
 	if err != nil {
-		log.Printf("problem setting tle source: %v\n", err)
-		return
+		return fmt.Errorf("problem setting tle source: %w", err)
 	}
 
 	defer o.Printer.Flush()
 	message := "Successfully changed TLE source."
 	o.Printer.Write([]interface{}{message})
+
+	return nil
 }